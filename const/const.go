@@ -7,6 +7,18 @@ const (
 	SoftDeleteFieldTagName            = "soft_delete_field"
 	CreateTimeFieldTagName            = "create_time_field"
 	UpdateTimeFieldTagName            = "update_time_field"
+	TenantFieldTagName                = "tenant_field"
+	IndexFieldTagName                 = "index"
+	ReadRolesTagName                  = "read_roles:"
+	WriteRolesTagName                 = "write_roles:"
+	SepOfRoles                        = "|"
 	FieldTagKey                       = "crud_generator"
 	ModelKey               ContextKey = "CURD_model"
+	ActionKey              ContextKey = "CURD_action"
+	TenantKey              ContextKey = "CURD_tenant"
+	SubjectKey             ContextKey = "CURD_subject"
+	ScopesKey              ContextKey = "CURD_scopes"
+	RoleKey                ContextKey = "CURD_role"
+	FieldsKey              ContextKey = "CURD_fields"
+	RequestLogKey          ContextKey = "CURD_request_log"
 )
@@ -0,0 +1,45 @@
+package core
+
+import (
+	"context"
+	"net/http"
+
+	constants "github.com/duytacong24895/go-crud-generator/const"
+)
+
+type Action string
+
+const (
+	ActionList   Action = "list"
+	ActionDetail Action = "detail"
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+var AllActions = []Action{ActionList, ActionDetail, ActionCreate, ActionUpdate, ActionDelete}
+
+// Method returns the http method of the action
+func (a Action) Method() string {
+	switch a {
+	case ActionCreate:
+		return http.MethodPost
+	case ActionUpdate:
+		return http.MethodPut
+	case ActionDelete:
+		return http.MethodDelete
+	}
+	return http.MethodGet
+}
+
+// IsRecordAction reports whether the action is done on a record, instead of the list of records
+func (a Action) IsRecordAction() bool {
+	return a == ActionDetail || a == ActionUpdate || a == ActionDelete
+}
+
+// ActionFromContext returns the action of the request, scopes can use it to
+// apply different rules to reads and writes
+func ActionFromContext(ctx context.Context) Action {
+	action, _ := ctx.Value(constants.ActionKey).(Action)
+	return action
+}
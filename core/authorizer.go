@@ -0,0 +1,32 @@
+package core
+
+import (
+	"context"
+
+	constants "github.com/duytacong24895/go-crud-generator/const"
+)
+
+// Authorizer decides if the subject of a request can do the action on the model
+// record is nil for list, the payload for create and the current record as it is stored for the others
+// Returning an error denies the request
+type Authorizer interface {
+	Can(ctx context.Context, subject any, model *Model, action Action, record *map[string]any) error
+}
+
+// ListScoper can be implemented by an Authorizer to narrow the get list queries
+// to the records the subject can see, a nil scope leaves the list of the model unchanged
+type ListScoper interface {
+	ListScope(ctx context.Context, subject any, model *Model) Scope
+}
+
+// SubjectFromContext returns the subject resolved for the request
+func SubjectFromContext(ctx context.Context) any {
+	return ctx.Value(constants.SubjectKey)
+}
+
+// ScopesFromContext returns the scopes added to the request, they are applied
+// together with the scopes of the model
+func ScopesFromContext(ctx context.Context) []Scope {
+	scopes, _ := ctx.Value(constants.ScopesKey).([]Scope)
+	return scopes
+}
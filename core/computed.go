@@ -0,0 +1,46 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	constants "github.com/duytacong24895/go-crud-generator/const"
+)
+
+// ComputedField is a field which is not a column, it's added to every record returned to the client
+type ComputedField struct {
+	Name string
+	// Compute returns the value of the field from the record
+	Compute func(ctx context.Context, record map[string]any) (any, error)
+	// Expr is the sql expression of the field, it's used instead of Compute
+	// The fields with an expression can also be filtered and sorted
+	Expr string
+	// Type is the sql type of the expression, e.g. INTEGER, the expression is cast to it so the values
+	// of the filters, which are sent as texts, are compared as values of this type
+	Type string
+}
+
+// SQL returns the expression of the field, cast to its type if it has one
+func (f *ComputedField) SQL() string {
+	if f.Type == "" {
+		return "(" + f.Expr + ")"
+	}
+	return fmt.Sprintf("CAST((%s) AS %s)", f.Expr, f.Type)
+}
+
+// ComputedField returns the computed field of the model by its name
+func (m *Model) ComputedField(name string) (*ComputedField, bool) {
+	for _, field := range m.ComputedFields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return nil, false
+}
+
+// FieldsFromContext returns the fields requested by the client,
+// all fields are returned if it's empty
+func FieldsFromContext(ctx context.Context) []string {
+	fields, _ := ctx.Value(constants.FieldsKey).([]string)
+	return fields
+}
@@ -30,15 +30,6 @@ func (c Core) IsPointeOfStruct(model any) bool {
 	return true
 }
 
-func (c Core) DetectModelInUse(listModels []*Model, modelName string) (*Model, bool) {
-	for _, model := range listModels {
-		if model.Name == modelName {
-			return model, true
-		}
-	}
-	return nil, false
-}
-
 func (c Core) ExactSchemaGorm(model any) map[string]string {
 	s, err := schema.Parse(model, &sync.Map{}, schema.NamingStrategy{})
 	if err != nil {
@@ -54,3 +45,51 @@ func (c Core) ExactSchemaGorm(model any) map[string]string {
 
 	return m
 }
+
+// ExactTableGorm returns the table name of the model, the TableName method of the model is respected
+func (c Core) ExactTableGorm(model any) string {
+	s, err := schema.Parse(model, schemas, schema.NamingStrategy{})
+	if err != nil {
+		panic("failed to create schema")
+	}
+	return s.Table
+}
+
+// ExactFieldsGorm returns the fields of the gorm schema which are mapped to a column
+func (c Core) ExactFieldsGorm(model any) []*ModelField {
+	s, err := schema.Parse(model, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		panic("failed to create schema")
+	}
+
+	var fields []*ModelField
+	for _, field := range s.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		fields = append(fields, &ModelField{
+			Name:     field.Name,
+			DBName:   field.DBName,
+			JSONName: jsonName(field),
+		})
+	}
+	return fields
+}
+
+// ExactPrimaryFieldGorm returns the prioritized primary field of the gorm schema
+// It will return nil if the model has no primary key
+func (c Core) ExactPrimaryFieldGorm(model any) *ModelField {
+	s, err := schema.Parse(model, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		panic("failed to create schema")
+	}
+
+	if s.PrioritizedPrimaryField == nil {
+		return nil
+	}
+	return &ModelField{
+		Name:     s.PrioritizedPrimaryField.Name,
+		DBName:   s.PrioritizedPrimaryField.DBName,
+		JSONName: jsonName(s.PrioritizedPrimaryField),
+	}
+}
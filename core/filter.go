@@ -3,9 +3,9 @@ package core
 import (
 	"encoding/json"
 	"fmt"
-	"reflect"
 	"strings"
 
+	"github.com/duytacong24895/go-crud-generator/errs"
 	"gorm.io/gorm"
 )
 
@@ -14,7 +14,7 @@ const SepOfBetween = "::"
 func convertToSqlOperator(op string) (string, error) {
 	sqlOp, ok := SuportedOperators[op]
 	if !ok {
-		return "", fmt.Errorf("unsupported operator: %s", op)
+		return "", errs.BadRequest(fmt.Sprintf("unsupported operator: %s", op), nil)
 	}
 	return string(sqlOp), nil
 }
@@ -23,6 +23,16 @@ type IFilter interface {
 	BuildQuery(db *gorm.DB) (*gorm.DB, error)
 	Load(filters string) error
 	IsEmpty() bool
+	// Columns returns the column names used by the conditions
+	Columns() []string
+	// MapColumns replaces the column names of the conditions by the result of fn
+	MapColumns(fn func(column string) string)
+	// Match reports whether the record, by its column names, satisfies the conditions
+	Match(record map[string]any) (bool, error)
+	// BuildSQL returns the where clause of the conditions with the placeholders of database/sql
+	BuildSQL(placeholder func(position int) string, args []any) (string, []any, error)
+	// Root returns the root of the conditions, it's nil when the filter is empty
+	Root() *Condition
 }
 
 type filter struct {
@@ -41,19 +51,19 @@ type Condition struct {
 
 func (f *filter) BuildQuery(db *gorm.DB) (*gorm.DB, error) {
 	if err := f.Conditions.BuildDiveQuery(db); err != nil {
-		return nil, fmt.Errorf("failed to build dive query: %w", err)
+		return nil, errs.BadRequest("", fmt.Errorf("failed to build dive query: %w", err))
 	}
 
 	var err error
 	f.Conditions.tx, err = f.Combine(f.Conditions)
 	if err != nil {
-		return nil, fmt.Errorf("failed to combine conditions: %w", err)
+		return nil, errs.BadRequest("", fmt.Errorf("failed to combine conditions: %w", err))
 	}
 	return f.Conditions.tx, nil
 }
 
 func (f *filter) Combine(con *Condition) (*gorm.DB, error) {
-	if con.ColumnName != "" && con.Value != "" {
+	if !con.IsBlock() {
 		return con.tx, nil
 	}
 
@@ -79,17 +89,21 @@ func (f *filter) Combine(con *Condition) (*gorm.DB, error) {
 
 func (c *Condition) BuildDiveQuery(db *gorm.DB) error {
 	if c.Left != nil {
-		c.Left.BuildDiveQuery(db)
+		if err := c.Left.BuildDiveQuery(db); err != nil {
+			return err
+		}
 	}
 	if c.Right != nil {
-		c.Right.BuildDiveQuery(db)
+		if err := c.Right.BuildDiveQuery(db); err != nil {
+			return err
+		}
 	}
 	operator, err := convertToSqlOperator(c.Operator)
 	if err != nil {
 		return err
 	}
 
-	if c.ColumnName != "" && c.Value != "" {
+	if !c.IsBlock() {
 		switch operator {
 		case "=":
 			c.tx = db.Where(fmt.Sprintf("%s = ?", c.ColumnName), c.Value)
@@ -138,26 +152,48 @@ func (f *filter) loadCondition(node *Condition, inputArr []interface{}) error {
 		return err
 	}
 
+	operator, ok := inputArr[1].(string)
+	if !ok {
+		return fmt.Errorf("operator must be a string: %v", inputArr[1])
+	}
+
 	if isleave {
-		node.ColumnName = inputArr[0].(string)
-		node.Operator = inputArr[1].(string)
-		node.Value = inputArr[2].(string)
+		columnName, ok := inputArr[0].(string)
+		if !ok {
+			return fmt.Errorf("column name must be a string: %v", inputArr[0])
+		}
+		value, ok := inputArr[2].(string)
+		if !ok {
+			return fmt.Errorf("value must be a string: %v", inputArr[2])
+		}
+		node.ColumnName = columnName
+		node.Operator = operator
+		node.Value = value
 		return nil
 	}
 
+	left, okLeft := inputArr[0].([]interface{})
+	right, okRight := inputArr[2].([]interface{})
+	if !okLeft || !okRight {
+		return fmt.Errorf("both sides of %s must be nested blocks", operator)
+	}
+
 	node.Left = &Condition{}
 	node.Right = &Condition{}
-	node.Operator = inputArr[1].(string)
-	f.loadCondition(node.Left, inputArr[0].([]interface{}))
-	f.loadCondition(node.Right, inputArr[2].([]interface{}))
-	return nil
+	node.Operator = operator
+	if err := f.loadCondition(node.Left, left); err != nil {
+		return err
+	}
+	return f.loadCondition(node.Right, right)
 }
 
 func isLeave(inputArr []interface{}) (bool, error) {
 	if len(inputArr) != 3 {
 		return false, fmt.Errorf("invalid filters length: %d", len(inputArr))
 	}
-	return !(reflect.TypeOf(inputArr[0]).Kind() == reflect.Slice || reflect.TypeOf(inputArr[2]).Kind() == reflect.Slice), nil
+	_, isLeftBlock := inputArr[0].([]interface{})
+	_, isRightBlock := inputArr[2].([]interface{})
+	return !(isLeftBlock || isRightBlock), nil
 }
 
 func (f *filter) Load(filters string) error {
@@ -168,23 +204,83 @@ func (f *filter) Load(filters string) error {
 
 	var inputArr []interface{}
 	if err := json.Unmarshal([]byte(filters), &inputArr); err != nil {
-		return fmt.Errorf("error parsing JSON: %w", err)
+		return errs.BadRequest("", fmt.Errorf("error parsing JSON: %w", err))
 	}
 
 	if len(inputArr) == 0 {
-		return fmt.Errorf("filters cannot be empty")
+		return errs.BadRequest("filters cannot be empty", nil)
 	}
 
 	if err := f.loadCondition(f.Conditions, inputArr); err != nil {
-		return fmt.Errorf("error loading conditions: %w", err)
+		return errs.BadRequest("", fmt.Errorf("error loading conditions: %w", err))
 	}
 	return nil
 }
 
+func (f *filter) Root() *Condition {
+	if f.isEmpty {
+		return nil
+	}
+	return f.Conditions
+}
+
+// IsBlock reports whether the condition combines the nested conditions with and/or,
+// a leaf has a column and no nested conditions, its value is empty for is null and is not null
+func (c *Condition) IsBlock() bool {
+	return c.ColumnName == "" || c.Left != nil || c.Right != nil
+}
+
+// SQLOperator returns the sql operator of the condition, e.g. = or between
+func (c *Condition) SQLOperator() (string, error) {
+	return convertToSqlOperator(c.Operator)
+}
+
 func (f *filter) IsEmpty() bool {
 	return f.isEmpty
 }
 
+func (f *filter) Columns() []string {
+	if f.isEmpty {
+		return nil
+	}
+	return f.Conditions.columns()
+}
+
+func (f *filter) MapColumns(fn func(column string) string) {
+	if f.isEmpty {
+		return
+	}
+	f.Conditions.mapColumns(fn)
+}
+
+func (c *Condition) mapColumns(fn func(column string) string) {
+	if c.Left == nil && c.Right == nil {
+		c.ColumnName = fn(c.ColumnName)
+		return
+	}
+	if c.Left != nil {
+		c.Left.mapColumns(fn)
+	}
+	if c.Right != nil {
+		c.Right.mapColumns(fn)
+	}
+}
+
+func (c *Condition) columns() []string {
+	if c.Left == nil && c.Right == nil {
+		return []string{c.ColumnName}
+	}
+
+	var columns []string
+	if c.Left != nil {
+		columns = append(columns, c.Left.columns()...)
+	}
+	if c.Right != nil {
+		columns = append(columns, c.Right.columns()...)
+	}
+	return columns
+}
+
 func NewFilter() IFilter {
 	return &filter{
 		Conditions: &Condition{},
@@ -0,0 +1,61 @@
+package core
+
+import "context"
+
+// Hooks are the callbacks run by the service around the CRUD operations of a model
+// The hooks of the writes are run inside the same transaction as the write,
+// returning an error aborts the operation and rolls the transaction back
+type Hooks struct {
+	BeforeCreate func(ctx context.Context, model *Model, payload *map[string]any) error
+	AfterCreate  func(ctx context.Context, model *Model, record *map[string]any) error
+	// record is the current row, payload is the data sent by the client
+	BeforeUpdate func(ctx context.Context, model *Model, record, payload *map[string]any) error
+	AfterUpdate  func(ctx context.Context, model *Model, record *map[string]any) error
+	BeforeDelete func(ctx context.Context, model *Model, record *map[string]any) error
+	AfterDelete  func(ctx context.Context, model *Model, record *map[string]any) error
+	// AfterRead is run on every record returned to the client
+	AfterRead func(ctx context.Context, model *Model, record *map[string]any) error
+}
+
+// Then returns the hooks running the hooks of h, then the ones of next
+func (h Hooks) Then(next Hooks) Hooks {
+	chained := Hooks{
+		BeforeCreate: chainHooks(h.BeforeCreate, next.BeforeCreate),
+		AfterCreate:  chainHooks(h.AfterCreate, next.AfterCreate),
+		BeforeUpdate: h.BeforeUpdate,
+		AfterUpdate:  chainHooks(h.AfterUpdate, next.AfterUpdate),
+		BeforeDelete: chainHooks(h.BeforeDelete, next.BeforeDelete),
+		AfterDelete:  chainHooks(h.AfterDelete, next.AfterDelete),
+		AfterRead:    chainHooks(h.AfterRead, next.AfterRead),
+	}
+	if previous := h.BeforeUpdate; previous == nil {
+		chained.BeforeUpdate = next.BeforeUpdate
+	} else if next.BeforeUpdate != nil {
+		chained.BeforeUpdate = func(ctx context.Context, model *Model, record, payload *map[string]any) error {
+			if err := previous(ctx, model, record, payload); err != nil {
+				return err
+			}
+			return next.BeforeUpdate(ctx, model, record, payload)
+		}
+	}
+	return chained
+}
+
+// recordHook is the signature of the hooks receiving a single record or payload
+type recordHook = func(ctx context.Context, model *Model, record *map[string]any) error
+
+// chainHooks returns the hook running first, then second, a nil hook is skipped
+func chainHooks(first, second recordHook) recordHook {
+	if first == nil {
+		return second
+	}
+	if second == nil {
+		return first
+	}
+	return func(ctx context.Context, model *Model, record *map[string]any) error {
+		if err := first(ctx, model, record); err != nil {
+			return err
+		}
+		return second(ctx, model, record)
+	}
+}
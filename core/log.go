@@ -0,0 +1,26 @@
+package core
+
+import (
+	"context"
+
+	constants "github.com/duytacong24895/go-crud-generator/const"
+)
+
+// RequestLog collects the attributes of the request which are logged and traced when the request is done,
+// the handler fills them while it runs the operation
+type RequestLog struct {
+	Action Action
+	ID     string
+	// Rows is the number of records read or written by the operation
+	Rows int
+	// Conditions is the number of conditions of the filter of a get list
+	Conditions int
+	// Err is the error responded to the client
+	Err error
+}
+
+// RequestLogFromContext returns the log of the request, it's nil when the requests are neither logged nor traced
+func RequestLogFromContext(ctx context.Context) *RequestLog {
+	entry, _ := ctx.Value(constants.RequestLogKey).(*RequestLog)
+	return entry
+}
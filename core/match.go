@@ -0,0 +1,201 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeLayouts are the layouts of the values compared to the time columns
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"}
+
+// Match reports whether the record satisfies the conditions, the same way the database does,
+// it's used by the repositories which don't run sql
+func (f *filter) Match(record map[string]any) (bool, error) {
+	if f.isEmpty {
+		return true, nil
+	}
+	matched, err := f.Conditions.match(record)
+	if err != nil {
+		return false, err
+	}
+	return matched, nil
+}
+
+func (c *Condition) match(record map[string]any) (bool, error) {
+	operator, err := convertToSqlOperator(c.Operator)
+	if err != nil {
+		return false, err
+	}
+
+	if c.IsBlock() {
+		if c.Left == nil || c.Right == nil {
+			return false, fmt.Errorf("both sides of %s must be nested blocks", c.Operator)
+		}
+		left, err := c.Left.match(record)
+		if err != nil {
+			return false, err
+		}
+		right, err := c.Right.match(record)
+		if err != nil {
+			return false, err
+		}
+		switch Operator(c.Operator) {
+		case AndOperator:
+			return left && right, nil
+		case OrOperator:
+			return left || right, nil
+		}
+		return false, fmt.Errorf("unsupported operator: %s", c.Operator)
+	}
+
+	value := record[c.ColumnName]
+	switch operator {
+	case "=":
+		return value != nil && CompareValues(value, c.Value) == 0, nil
+	case ">":
+		return value != nil && CompareValues(value, c.Value) > 0, nil
+	case "<":
+		return value != nil && CompareValues(value, c.Value) < 0, nil
+	case ">=":
+		return value != nil && CompareValues(value, c.Value) >= 0, nil
+	case "<=":
+		return value != nil && CompareValues(value, c.Value) <= 0, nil
+	case "!=":
+		return value != nil && CompareValues(value, c.Value) != 0, nil
+	case "like":
+		return value != nil && like(c.Value).MatchString(fmt.Sprint(value)), nil
+	case "not like":
+		return value != nil && !like(c.Value).MatchString(fmt.Sprint(value)), nil
+	case "between", "not between":
+		sep := SepOfBetween
+		if operator == "not between" {
+			sep = ","
+		}
+		values := strings.Split(c.Value, sep)
+		if len(values) != 2 {
+			return false, fmt.Errorf("invalid value for %s operator: %s", operator, c.Value)
+		}
+		between := CompareValues(value, values[0]) >= 0 && CompareValues(value, values[1]) <= 0
+		return value != nil && between == (operator == "between"), nil
+	case "is null":
+		return value == nil, nil
+	case "is not null":
+		return value != nil, nil
+	}
+	return false, fmt.Errorf(`%s is unsupported operator or invalid input`, c.Operator)
+}
+
+// like converts the pattern of the sql like operator to a regexp, it's case insensitive as in sqlite
+func like(pattern string) *regexp.Regexp {
+	var expr strings.Builder
+	expr.WriteString("(?is)^")
+	for _, char := range pattern {
+		switch char {
+		case '%':
+			expr.WriteString(".*")
+		case '_':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(char)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.MustCompile(expr.String())
+}
+
+// CompareValues compares a to b, b is converted to the type of a when it's a string,
+// nil is less than every value
+func CompareValues(a, b any) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	if x, ok := toFloat(a); ok {
+		if y, ok := toFloat(b); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	if x, ok := a.(time.Time); ok {
+		if y, ok := ToTime(b); ok {
+			return x.Compare(y)
+		}
+	}
+	if x, ok := a.(bool); ok {
+		if y, err := strconv.ParseBool(fmt.Sprint(b)); err == nil {
+			switch {
+			case x == y:
+				return 0
+			case !x:
+				return -1
+			}
+			return 1
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// ToTime converts the value to a time, the strings are parsed by the layouts of the filters
+func ToTime(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
@@ -1,28 +1,110 @@
 package core
 
 import (
+	"net/http"
 	"reflect"
 	"slices"
 	"strings"
+	"time"
 
 	constants "github.com/duytacong24895/go-crud-generator/const"
 )
 
 type Model struct {
 	Name string `json:"name"`
-	Ref  any
-	Meta *MetaModel
+	// Resource is the segment of the model in the urls, it's set by the naming strategy if it's empty
+	Resource string `json:"resource"`
+	Ref      any
+	Meta     *MetaModel
+	Hooks    Hooks
+	Scopes   []Scope
+	// Actions are the enabled actions of the model, all actions are enabled if it's empty
+	Actions []Action
+	// Middlewares and DTOs of the model, they are used together with or instead of the global ones
+	Middlewares  []func(next http.Handler) http.Handler
+	DTOGetDetail func(w http.ResponseWriter, r *http.Request, ref any) any
+	DTOGetList   func(w http.ResponseWriter, r *http.Request, ref any, total, page, pageSize uint) any
+	DTOError     func(w http.ResponseWriter, r *http.Request, err error, errMsg string, status int) any
+	// ComputedFields are added to the records returned to the client
+	ComputedFields []*ComputedField
+	// Typed is set for the models registered with Register[T], their records are scanned into the struct
+	// of the model and returned by its json encoding
+	Typed bool
+	// QueryTimeout is the deadline of the requests of the model, the default of the generator is used if it's zero
+	QueryTimeout time.Duration
+}
+
+// Allows reports whether the action is enabled for the model
+func (m *Model) Allows(action Action) bool {
+	return len(m.Actions) == 0 || slices.Contains(m.Actions, action)
 }
 
 type MetaModel struct {
+	PrimaryField     *ModelField
 	SoftDeletedField *ModelField
 	CreatedAtField   *ModelField
 	UpdatedAtField   *ModelField
+	TenantField      *ModelField
+	// IndexedFields are the fields marked with the index tag, the repositories without a database
+	// keep a secondary index of their values
+	IndexedFields    []*ModelField
+	Fields           []*ModelField
+	FieldPermissions []*FieldPermission
 }
 
 type ModelField struct {
-	Name   string `json:"name"` // Name of the field in the struct
-	DBName string `json:"db_name"`
+	Name     string `json:"name"` // Name of the field in the struct
+	DBName   string `json:"db_name"`
+	JSONName string `json:"json_name"`
+}
+
+// Field returns the field of the model by its struct name, then its db name, then its json name,
+// so a name shared by two fields, e.g. the json name of a field which is the column of another one,
+// always refers to the same field
+func (m *MetaModel) Field(name string) (*ModelField, bool) {
+	for _, key := range []func(field *ModelField) string{
+		func(field *ModelField) string { return field.Name },
+		func(field *ModelField) string { return field.DBName },
+		func(field *ModelField) string { return field.JSONName },
+	} {
+		for _, field := range m.Fields {
+			if key(field) != "" && key(field) == name {
+				return field, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Keys returns the keys of data which refer to the field, the keys are matched by Field
+// and the fields by their struct name
+func (m *MetaModel) Keys(data map[string]any, field *ModelField) []string {
+	var keys []string
+	for key := range data {
+		if found, ok := m.Field(key); ok && found.Name == field.Name {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// RecordKey returns the key of the field in the records of the model, the json name for the typed models
+// as their records are the json encoding of their struct, the column otherwise
+func (m *Model) RecordKey(field *ModelField) string {
+	if m.Typed {
+		return field.JSONName
+	}
+	return field.DBName
+}
+
+// PrimaryKey returns the column name of the primary key, "id" is used when
+// the model doesn't declare one
+func (m *MetaModel) PrimaryKey() string {
+	if m.PrimaryField == nil {
+		return "id"
+	}
+	return m.PrimaryField.DBName
 }
 
 func NewModel(ref any) *Model {
@@ -38,13 +120,19 @@ func NewMetaModel(ref any) *MetaModel {
 	/*
 		This function extracts metadata from the model reference.
 		It looks for specific struct tags to identify fields related to soft deletion,
-		creation, and update timestamps.
+		creation, and update timestamps, and the tenant of the records.
 
 		If you are using from gorm.Model in your struct, You don't need to set these tags.
 	*/
 	gormSchema := Core{}.ExactSchemaGorm(ref)
+	meta := &MetaModel{
+		PrimaryField: Core{}.ExactPrimaryFieldGorm(ref),
+		Fields:       Core{}.ExactFieldsGorm(ref),
+	}
 	numField := reflect.TypeOf(ref).Elem().NumField()
-	var softDeletedField, createdAtField, updatedAtField *ModelField
+	var softDeletedField, createdAtField, updatedAtField, tenantField *ModelField
+	var fieldPermissions []*FieldPermission
+	var indexedFields []*ModelField
 	for i := 0; i < numField; i++ {
 		field := reflect.TypeOf(ref).Elem().Field(i)
 		tags := field.Tag.Get(constants.FieldTagKey)
@@ -52,6 +140,16 @@ func NewMetaModel(ref any) *MetaModel {
 			continue
 		}
 		arrTags := strings.Split(tags, constants.SepOfTags)
+		modelField := &ModelField{Name: field.Name, DBName: gormSchema[field.Name]}
+		if found, ok := meta.Field(field.Name); ok {
+			modelField = found
+		}
+		if permission := newFieldPermission(modelField, arrTags); permission != nil {
+			fieldPermissions = append(fieldPermissions, permission)
+		}
+		if slices.Contains(arrTags, constants.IndexFieldTagName) {
+			indexedFields = append(indexedFields, modelField)
+		}
 		if slices.Contains(arrTags, constants.SoftDeleteFieldTagName) {
 			softDeletedField = &ModelField{
 				Name:   field.Name,
@@ -67,11 +165,40 @@ func NewMetaModel(ref any) *MetaModel {
 				Name:   field.Name,
 				DBName: gormSchema[field.Name],
 			}
+		} else if slices.Contains(arrTags, constants.TenantFieldTagName) {
+			tenantField = &ModelField{
+				Name:   field.Name,
+				DBName: gormSchema[field.Name],
+			}
 		}
 	}
-	return &MetaModel{
-		SoftDeletedField: softDeletedField,
-		CreatedAtField:   createdAtField,
-		UpdatedAtField:   updatedAtField,
+	meta.SoftDeletedField = softDeletedField
+	meta.CreatedAtField = createdAtField
+	meta.UpdatedAtField = updatedAtField
+	meta.TenantField = tenantField
+	meta.FieldPermissions = fieldPermissions
+	meta.IndexedFields = indexedFields
+	return meta
+}
+
+// newFieldPermission reads the roles of the read_roles and write_roles tags
+// Example: `crud_generator:"read_roles:admin|hr,write_roles:admin"`
+func newFieldPermission(field *ModelField, arrTags []string) *FieldPermission {
+	var readRoles, writeRoles []string
+	for _, tag := range arrTags {
+		if roles, ok := strings.CutPrefix(tag, constants.ReadRolesTagName); ok {
+			readRoles = strings.Split(roles, constants.SepOfRoles)
+		} else if roles, ok := strings.CutPrefix(tag, constants.WriteRolesTagName); ok {
+			writeRoles = strings.Split(roles, constants.SepOfRoles)
+		}
+	}
+
+	if readRoles == nil && writeRoles == nil {
+		return nil
+	}
+	return &FieldPermission{
+		Field:      field,
+		ReadRoles:  readRoles,
+		WriteRoles: writeRoles,
 	}
 }
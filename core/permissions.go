@@ -0,0 +1,52 @@
+package core
+
+import (
+	"context"
+	"slices"
+
+	constants "github.com/duytacong24895/go-crud-generator/const"
+)
+
+// FieldPermission restricts the roles which can read or write a field
+// An empty list of roles means every role is allowed
+type FieldPermission struct {
+	Field      *ModelField
+	ReadRoles  []string
+	WriteRoles []string
+}
+
+func (p *FieldPermission) CanRead(role string) bool {
+	return len(p.ReadRoles) == 0 || slices.Contains(p.ReadRoles, role)
+}
+
+func (p *FieldPermission) CanWrite(role string) bool {
+	return len(p.WriteRoles) == 0 || slices.Contains(p.WriteRoles, role)
+}
+
+// RoleFromContext returns the role resolved for the request
+func RoleFromContext(ctx context.Context) string {
+	role, _ := ctx.Value(constants.RoleKey).(string)
+	return role
+}
+
+// UnreadableFields returns the fields of the model which the role can't read
+func (m *MetaModel) UnreadableFields(role string) []*ModelField {
+	var fields []*ModelField
+	for _, permission := range m.FieldPermissions {
+		if !permission.CanRead(role) {
+			fields = append(fields, permission.Field)
+		}
+	}
+	return fields
+}
+
+// UnwritableFields returns the fields of the model which the role can't write
+func (m *MetaModel) UnwritableFields(role string) []*ModelField {
+	var fields []*ModelField
+	for _, permission := range m.FieldPermissions {
+		if !permission.CanWrite(role) {
+			fields = append(fields, permission.Field)
+		}
+	}
+	return fields
+}
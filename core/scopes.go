@@ -0,0 +1,11 @@
+package core
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Scope narrows the statements of a model, it's applied to the get list, get detail, update and delete statements
+// Records outside the scope are considered as not found, the created records are not checked against it
+type Scope func(ctx context.Context, db *gorm.DB) *gorm.DB
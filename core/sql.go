@@ -0,0 +1,63 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BuildSQL returns the where clause of the conditions for database/sql, the values are appended to args
+// and their placeholders are returned by placeholder with their position in args, e.g. ? or $1
+func (f *filter) BuildSQL(placeholder func(position int) string, args []any) (string, []any, error) {
+	if f.isEmpty {
+		return "", args, nil
+	}
+	return f.Conditions.buildSQL(placeholder, args)
+}
+
+func (c *Condition) buildSQL(placeholder func(position int) string, args []any) (string, []any, error) {
+	operator, err := convertToSqlOperator(c.Operator)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if c.IsBlock() {
+		if c.Left == nil || c.Right == nil {
+			return "", nil, fmt.Errorf("both sides of %s must be nested blocks", c.Operator)
+		}
+		if Operator(c.Operator) != AndOperator && Operator(c.Operator) != OrOperator {
+			return "", nil, fmt.Errorf("unsupported operator: %s", c.Operator)
+		}
+		left, args, err := c.Left.buildSQL(placeholder, args)
+		if err != nil {
+			return "", nil, err
+		}
+		right, args, err := c.Right.buildSQL(placeholder, args)
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("(%s %s %s)", left, strings.ToUpper(operator), right), args, nil
+	}
+
+	bind := func(value string) string {
+		args = append(args, value)
+		return placeholder(len(args))
+	}
+	switch operator {
+	case "=", ">", "<", ">=", "<=", "!=", "like", "not like":
+		return fmt.Sprintf("%s %s %s", c.ColumnName, strings.ToUpper(operator), bind(c.Value)), args, nil
+	case "between", "not between":
+		sep := SepOfBetween
+		if operator == "not between" {
+			sep = ","
+		}
+		values := strings.Split(c.Value, sep)
+		if len(values) != 2 {
+			return "", nil, fmt.Errorf("invalid value for %s operator: %s", operator, c.Value)
+		}
+		from := bind(values[0])
+		return fmt.Sprintf("%s %s %s AND %s", c.ColumnName, strings.ToUpper(operator), from, bind(values[1])), args, nil
+	case "is null", "is not null":
+		return fmt.Sprintf("%s %s", c.ColumnName, strings.ToUpper(operator)), args, nil
+	}
+	return "", nil, fmt.Errorf(`%s is unsupported operator or invalid input`, c.Operator)
+}
@@ -0,0 +1,13 @@
+package core
+
+import (
+	"context"
+
+	constants "github.com/duytacong24895/go-crud-generator/const"
+)
+
+// TenantFromContext returns the tenant resolved for the request
+func TenantFromContext(ctx context.Context) (any, bool) {
+	tenant := ctx.Value(constants.TenantKey)
+	return tenant, tenant != nil
+}
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm/schema"
+)
+
+// schemas caches the gorm schemas of the typed models, they are parsed for every record
+var schemas = &sync.Map{}
+
+// jsonName returns the name of the field in the json encoding of the struct
+func jsonName(field *schema.Field) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	}
+	return name
+}
+
+// ScanStruct sets the columns of the record to a new struct of the type of ref,
+// the gorm serializers and the sql.Scanner of the fields are applied
+func (c Core) ScanStruct(ctx context.Context, ref any, record map[string]any) (any, error) {
+	s, err := schema.Parse(ref, schemas, schema.NamingStrategy{})
+	if err != nil {
+		return nil, err
+	}
+
+	value := reflect.New(reflect.TypeOf(ref).Elem())
+	for _, field := range s.Fields {
+		column, ok := record[field.DBName]
+		if field.DBName == "" || !ok {
+			continue
+		}
+		if field.Serializer != nil {
+			err = field.Serializer.Scan(ctx, field, value.Elem(), column)
+		} else {
+			err = field.Set(ctx, value.Elem(), column)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return value.Interface(), nil
+}
+
+// ColumnValues returns the values of the columns of the fields of record, by their db names,
+// the gorm serializers of the fields are applied
+func (c Core) ColumnValues(ctx context.Context, record any, names []string) (map[string]any, error) {
+	s, err := schema.Parse(record, schemas, schema.NamingStrategy{})
+	if err != nil {
+		return nil, err
+	}
+
+	value := reflect.ValueOf(record).Elem()
+	columns := make(map[string]any, len(names))
+	for _, name := range names {
+		field := s.LookUpField(name)
+		if field == nil || field.DBName == "" {
+			continue
+		}
+		fieldValue, _ := field.ValueOf(ctx, value)
+		// The values of the fields with a serializer are wrapped by a valuer which serializes them
+		if valuer, ok := fieldValue.(driver.Valuer); ok && field.Serializer != nil {
+			if fieldValue, err = valuer.Value(); err != nil {
+				return nil, err
+			}
+		}
+		columns[field.DBName] = fieldValue
+	}
+	return columns, nil
+}
+
+// StructToMap returns the json encoding of value as a map, the numbers are kept as json.Number
+func (c Core) StructToMap(value any) (map[string]any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var record map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// MapToStruct decodes the record into value by its json encoding
+func (c Core) MapToStruct(record map[string]any, value any) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
@@ -1,10 +1,12 @@
 package dtos
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/duytacong24895/go-crud-generator/core"
+	"github.com/duytacong24895/go-crud-generator/errs"
 )
 
 type GetListQueryParams struct {
@@ -22,7 +24,7 @@ func (g *GetListQueryParams) Bind(r *http.Request) error {
 		var err error
 		g.Page, err = strconv.Atoi(rPage)
 		if err != nil {
-			return err
+			return errs.BadRequest(fmt.Sprintf("invalid page: %s", rPage), err)
 		}
 	} else {
 		g.Page = 0
@@ -33,7 +35,7 @@ func (g *GetListQueryParams) Bind(r *http.Request) error {
 		var err error
 		g.PageSize, err = strconv.Atoi(rPageSize)
 		if err != nil {
-			return err
+			return errs.BadRequest(fmt.Sprintf("invalid page_size: %s", rPageSize), err)
 		}
 	} else {
 		g.PageSize = 0
@@ -0,0 +1,71 @@
+package dtos
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/duytacong24895/go-crud-generator/errs"
+)
+
+const (
+	ProblemContentType = "application/problem+json"
+	RequestIDHeader    = "X-Request-Id"
+)
+
+// ProblemDetails is the error response defined by RFC 7807
+// Extensions are marshaled as the members of the object
+type ProblemDetails struct {
+	Type       string         `json:"type"`
+	Title      string         `json:"title"`
+	Status     int            `json:"status"`
+	Detail     string         `json:"detail,omitempty"`
+	Instance   string         `json:"instance,omitempty"`
+	Extensions map[string]any `json:"-"`
+}
+
+func (p *ProblemDetails) MarshalJSON() ([]byte, error) {
+	members := make(map[string]any, len(p.Extensions)+5)
+	for key, value := range p.Extensions {
+		members[key] = value
+	}
+	members["type"] = p.Type
+	members["title"] = p.Title
+	members["status"] = p.Status
+	if p.Detail != "" {
+		members["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		members["instance"] = p.Instance
+	}
+	return json.Marshal(members)
+}
+
+// ProblemDetailsError is the default DTO for errors, it can also be registered with RegisterDTOForError
+// The field errors and the request id are added as extension members. The message of the internal errors
+// isn't sent to the client, as it can contain the errors of the database, their cause is logged with the request
+func ProblemDetailsError(w http.ResponseWriter, r *http.Request, err error, errMsg string, status int) any {
+	e := errs.As(err)
+	if e.Kind == errs.KindInternal {
+		errMsg = http.StatusText(status)
+	}
+	title := http.StatusText(status)
+	if status == errs.StatusClientClosedRequest {
+		title = "Client Closed Request"
+	}
+	problem := &ProblemDetails{
+		Type:       "about:blank",
+		Title:      title,
+		Status:     status,
+		Detail:     errMsg,
+		Instance:   r.URL.Path,
+		Extensions: make(map[string]any),
+	}
+
+	if len(e.Fields) > 0 {
+		problem.Extensions["errors"] = e.Fields
+	}
+	if requestID := r.Header.Get(RequestIDHeader); requestID != "" {
+		problem.Extensions["request_id"] = requestID
+	}
+	return problem
+}
@@ -0,0 +1,53 @@
+// Package echo mounts the routes of the CRUD generator on an echo router
+package echo
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	labstack "github.com/labstack/echo/v4"
+
+	crud_generator "github.com/duytacong24895/go-crud-generator"
+)
+
+type contextKey struct{}
+
+// params matches the params of the patterns of the generator, {name} is :name in echo
+var params = regexp.MustCompile(`\{(\w+)\}`)
+
+// Register registers the routes of generator on group, the middlewares are run after the ones of group
+// Example:
+//
+//	generator := crud_generator.NewCRUDGenerator(nil, db).RegisterModel(&models.User{})
+//	echo.Register(e.Group("/api"), generator, authMiddleware)
+func Register(group *labstack.Group, generator crud_generator.ICRUDGenerator, middlewares ...labstack.MiddlewareFunc) {
+	for _, route := range generator.Routes() {
+		path := params.ReplaceAllString(route.Pattern, ":$1")
+		if route.Method == "" {
+			group.Any(path, handle(route.Handler), middlewares...)
+			continue
+		}
+		group.Add(route.Method, path, handle(route.Handler), middlewares...)
+	}
+}
+
+// handle runs the handler of the generator with the params of the echo context,
+// the echo context is put into the context of the request
+func handle(handler http.Handler) labstack.HandlerFunc {
+	return func(c labstack.Context) error {
+		r := c.Request().WithContext(context.WithValue(c.Request().Context(), contextKey{}, c))
+		for i, name := range c.ParamNames() {
+			r.SetPathValue(name, c.ParamValues()[i])
+		}
+		handler.ServeHTTP(c.Response(), r)
+		return nil
+	}
+}
+
+// FromRequest returns the echo context of the request, so the resolvers and the hooks
+// can read the values set by the echo middlewares
+func FromRequest(r *http.Request) (labstack.Context, bool) {
+	c, ok := r.Context().Value(contextKey{}).(labstack.Context)
+	return c, ok
+}
@@ -0,0 +1,26 @@
+module github.com/duytacong24895/go-crud-generator/echo
+
+go 1.24.1
+
+require (
+	github.com/duytacong24895/go-crud-generator v0.0.0-20241022120000-abcdef123456
+	github.com/labstack/echo/v4 v4.12.0
+)
+
+require (
+	github.com/jinzhu/inflection v1.0.0 // indirect
+	github.com/jinzhu/now v1.1.5 // indirect
+	github.com/labstack/gommon v0.4.2 // indirect
+	github.com/mattn/go-colorable v0.1.13 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	github.com/valyala/fasttemplate v1.2.2 // indirect
+	go.etcd.io/bbolt v1.4.3 // indirect
+	golang.org/x/crypto v0.36.0 // indirect
+	golang.org/x/net v0.37.0 // indirect
+	golang.org/x/sys v0.31.0 // indirect
+	golang.org/x/text v0.23.0 // indirect
+	gorm.io/gorm v1.30.0 // indirect
+)
+
+replace github.com/duytacong24895/go-crud-generator => ../
@@ -0,0 +1,165 @@
+package errs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+type Kind string
+
+const (
+	KindNotFound   Kind = "not_found"
+	KindValidation Kind = "validation"
+	KindConflict   Kind = "conflict"
+	KindBadRequest Kind = "bad_request"
+	KindForbidden  Kind = "forbidden"
+	KindInternal   Kind = "internal"
+	KindTimeout    Kind = "timeout"
+	KindCanceled   Kind = "canceled"
+
+	KindMethodNotAllowed Kind = "method_not_allowed"
+)
+
+// StatusClientClosedRequest is the status of the requests cancelled by the client, as nginx logs them,
+// net/http has no constant for it
+const StatusClientClosedRequest = 499
+
+var statusCodes = map[Kind]int{
+	KindNotFound:   http.StatusNotFound,
+	KindValidation: http.StatusUnprocessableEntity,
+	KindConflict:   http.StatusConflict,
+	KindBadRequest: http.StatusBadRequest,
+	KindForbidden:  http.StatusForbidden,
+	KindInternal:   http.StatusInternalServerError,
+	KindTimeout:    http.StatusGatewayTimeout,
+	KindCanceled:   StatusClientClosedRequest,
+
+	KindMethodNotAllowed: http.StatusMethodNotAllowed,
+}
+
+// Sentinels of each kind, they can be used with errors.Is
+// Example: errors.Is(err, errs.ErrNotFound)
+var (
+	ErrNotFound   = &Error{Kind: KindNotFound}
+	ErrValidation = &Error{Kind: KindValidation}
+	ErrConflict   = &Error{Kind: KindConflict}
+	ErrBadRequest = &Error{Kind: KindBadRequest}
+	ErrForbidden  = &Error{Kind: KindForbidden}
+	ErrInternal   = &Error{Kind: KindInternal}
+	ErrTimeout    = &Error{Kind: KindTimeout}
+	ErrCanceled   = &Error{Kind: KindCanceled}
+
+	ErrMethodNotAllowed = &Error{Kind: KindMethodNotAllowed}
+)
+
+// Error is the error raised by the layers of the generator,
+// its kind decides the http status code of the response
+type Error struct {
+	Kind    Kind
+	Message string
+	Fields  map[string]string // Errors of each field, mostly used by validation errors
+	Err     error             // The original error
+}
+
+func (e *Error) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return string(e.Kind)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the target is an *Error of the same kind
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return t.Kind == e.Kind
+}
+
+func (e *Error) StatusCode() int {
+	if status, ok := statusCodes[e.Kind]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// WithField adds an error message for a field
+func (e *Error) WithField(name, msg string) *Error {
+	if e.Fields == nil {
+		e.Fields = make(map[string]string)
+	}
+	e.Fields[name] = msg
+	return e
+}
+
+// The constructors below create an error of each kind,
+// msg can be empty to use the message of err
+func NotFound(msg string, err error) *Error {
+	return &Error{Kind: KindNotFound, Message: msg, Err: err}
+}
+
+func Validation(msg string, err error) *Error {
+	return &Error{Kind: KindValidation, Message: msg, Err: err}
+}
+
+func Conflict(msg string, err error) *Error {
+	return &Error{Kind: KindConflict, Message: msg, Err: err}
+}
+
+func BadRequest(msg string, err error) *Error {
+	return &Error{Kind: KindBadRequest, Message: msg, Err: err}
+}
+
+func Forbidden(msg string, err error) *Error {
+	return &Error{Kind: KindForbidden, Message: msg, Err: err}
+}
+
+func Internal(msg string, err error) *Error {
+	return &Error{Kind: KindInternal, Message: msg, Err: err}
+}
+
+func Timeout(msg string, err error) *Error {
+	return &Error{Kind: KindTimeout, Message: msg, Err: err}
+}
+
+func Canceled(msg string, err error) *Error {
+	return &Error{Kind: KindCanceled, Message: msg, Err: err}
+}
+
+func MethodNotAllowed(msg string, err error) *Error {
+	return &Error{Kind: KindMethodNotAllowed, Message: msg, Err: err}
+}
+
+// As returns the *Error in the chain of err, the exceeded deadlines are considered as timeouts,
+// the cancelled contexts as cancellations and the other errors which are not raised by the generator
+// as internal errors
+func As(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return Timeout("", err)
+	}
+	if errors.Is(err, context.Canceled) {
+		return Canceled("", err)
+	}
+	return Internal("", err)
+}
+
+// StatusCode returns the http status code of err
+func StatusCode(err error) int {
+	if err == nil {
+		return http.StatusInternalServerError
+	}
+	return As(err).StatusCode()
+}
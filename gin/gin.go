@@ -0,0 +1,53 @@
+// Package gin mounts the routes of the CRUD generator on a gin router
+package gin
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	gingonic "github.com/gin-gonic/gin"
+
+	crud_generator "github.com/duytacong24895/go-crud-generator"
+)
+
+type contextKey struct{}
+
+// params matches the params of the patterns of the generator, {name} is :name in gin
+var params = regexp.MustCompile(`\{(\w+)\}`)
+
+// Register registers the routes of generator on group, the middlewares are run after the ones of group
+// Example:
+//
+//	generator := crud_generator.NewCRUDGenerator(nil, db).RegisterModel(&models.User{})
+//	gin.Register(engine.Group("/api"), generator, authMiddleware)
+func Register(group *gingonic.RouterGroup, generator crud_generator.ICRUDGenerator, middlewares ...gingonic.HandlerFunc) {
+	for _, route := range generator.Routes() {
+		path := params.ReplaceAllString(route.Pattern, ":$1")
+		handlers := append(append([]gingonic.HandlerFunc{}, middlewares...), handle(route.Handler))
+		if route.Method == "" {
+			group.Any(path, handlers...)
+			continue
+		}
+		group.Handle(route.Method, path, handlers...)
+	}
+}
+
+// handle runs the handler of the generator with the params of the gin context,
+// the gin context is put into the context of the request
+func handle(handler http.Handler) gingonic.HandlerFunc {
+	return func(c *gingonic.Context) {
+		r := c.Request.WithContext(context.WithValue(c.Request.Context(), contextKey{}, c))
+		for _, param := range c.Params {
+			r.SetPathValue(param.Key, param.Value)
+		}
+		handler.ServeHTTP(c.Writer, r)
+	}
+}
+
+// FromRequest returns the gin context of the request, so the resolvers and the hooks
+// can read the values set by the gin middlewares
+func FromRequest(r *http.Request) (*gingonic.Context, bool) {
+	c, ok := r.Context().Value(contextKey{}).(*gingonic.Context)
+	return c, ok
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	constants "github.com/duytacong24895/go-crud-generator/const"
+	"github.com/duytacong24895/go-crud-generator/core"
+	"github.com/duytacong24895/go-crud-generator/errs"
+	"github.com/duytacong24895/go-crud-generator/services"
+)
+
+// ActionContext is passed to the handlers of the custom actions
+type ActionContext struct {
+	Model *core.Model
+	// Record is the record of the id in the url, it's nil for the actions on the model
+	Record *map[string]any
+	// Service is bound to the transaction of the action,
+	// the transaction is rolled back if the handler returns an error
+	Service services.IService
+}
+
+// ActionFunc handles a custom action, the returned value is responded with the DTO of get detail,
+// unless the handler wrote the response itself with w
+type ActionFunc func(w http.ResponseWriter, r *http.Request, action *ActionContext) (any, error)
+
+type CustomAction struct {
+	Name    string
+	Method  string
+	Handler ActionFunc
+}
+
+// AddAction registers a custom action for the model
+func (h *Handler) AddAction(model *core.Model, action *CustomAction) {
+	if h.CustomActions == nil {
+		h.CustomActions = make(map[string]map[string]*CustomAction)
+	}
+	if h.CustomActions[model.Name] == nil {
+		h.CustomActions[model.Name] = make(map[string]*CustomAction)
+	}
+	h.CustomActions[model.Name][action.Name] = action
+}
+
+// RunAction runs the custom action of the model, on the record if the id is in the url
+func (h *Handler) RunAction(w http.ResponseWriter, r *http.Request) {
+	model, ok := r.Context().Value(constants.ModelKey).(*core.Model)
+	if !ok {
+		err := errs.Internal("Model not found in context", nil)
+		h.ResponseError(w, r, err, err.Error())
+		return
+	}
+
+	name := r.PathValue("action")
+	action, ok := h.CustomActions[model.Name][name]
+	if !ok {
+		err := errs.NotFound(fmt.Sprintf("action %s not found for %s", name, model.Name), nil)
+		h.ResponseError(w, r, err, err.Error())
+		return
+	}
+	if action.Method != r.Method {
+		w.Header().Set("Allow", action.Method)
+		err := errs.MethodNotAllowed(fmt.Sprintf("action %s only accepts %s", name, action.Method), nil)
+		h.ResponseError(w, r, err, err.Error())
+		return
+	}
+
+	ctx, err := h.requestContext(r, core.Action(name))
+	if err != nil {
+		h.ResponseError(w, r, err, err.Error())
+		return
+	}
+
+	writer := &actionWriter{ResponseWriter: w}
+	var res any
+	err = h.Service.Transaction(ctx, model, func(tx services.IService) error {
+		var record *map[string]any
+		if id := r.PathValue("id"); id != "" {
+			if err := h.authorizeRecord(ctx, tx, model, id); err != nil {
+				return err
+			}
+			var err error
+			if record, err = tx.GetByID(ctx, model, id); err != nil {
+				return err
+			}
+		} else if err := h.authorize(ctx, model, nil); err != nil {
+			return err
+		}
+
+		var err error
+		res, err = action.Handler(writer, r.WithContext(ctx), &ActionContext{
+			Model:   model,
+			Record:  record,
+			Service: tx,
+		})
+		return err
+	})
+	if writer.written {
+		// The response is already sent, the error can only be logged
+		if entry := core.RequestLogFromContext(ctx); entry != nil && err != nil {
+			entry.Err = err
+		}
+		return
+	}
+	if err != nil {
+		h.ResponseError(w, r, err, err.Error())
+		return
+	}
+	h.ResponseDetail(w, r, res)
+}
+
+// actionWriter reports whether the handler of an action wrote the response itself
+type actionWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (a *actionWriter) WriteHeader(status int) {
+	a.written = true
+	a.ResponseWriter.WriteHeader(status)
+}
+
+func (a *actionWriter) Write(b []byte) (int, error) {
+	a.written = true
+	return a.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the writer of the server
+func (a *actionWriter) Unwrap() http.ResponseWriter {
+	return a.ResponseWriter
+}
@@ -1,167 +1,418 @@
 package handler
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
-
-	"github.com/go-chi/chi/v5"
+	"path"
+	"strings"
 
 	constants "github.com/duytacong24895/go-crud-generator/const"
 	"github.com/duytacong24895/go-crud-generator/core"
 	"github.com/duytacong24895/go-crud-generator/dtos"
+	"github.com/duytacong24895/go-crud-generator/errs"
+	"github.com/duytacong24895/go-crud-generator/runtime"
 	"github.com/duytacong24895/go-crud-generator/services"
 )
 
 type Handler struct {
 	Service      services.IService
-	ListModels   []*core.Model
+	Models       *runtime.RegisteredModels
 	DTOGetDetail func(w http.ResponseWriter, r *http.Request, ref any) any
 	DTOGetList   func(w http.ResponseWriter, r *http.Request, ref any, total, page, pageSize uint) any
-	DTOError     func(w http.ResponseWriter, r *http.Request, err error, errMsg string) any
+	DTOError     func(w http.ResponseWriter, r *http.Request, err error, errMsg string, status int) any
+	// TenantResolver resolves the tenant of the request, the records of the models
+	// with a tenant field are filtered and stamped by it
+	TenantResolver func(r *http.Request) (any, error)
+	// Authorizer is consulted before every operation with the subject resolved by SubjectResolver
+	Authorizer      core.Authorizer
+	SubjectResolver func(r *http.Request) (any, error)
+	// RoleResolver extracts the role of the request from its context,
+	// the field permissions of the models are evaluated against it
+	RoleResolver func(ctx context.Context) string
+	// CustomActions are the custom actions of each model, by the name of the model and the name of the action
+	CustomActions map[string]map[string]*CustomAction
 }
 
 func (h *Handler) GetList(w http.ResponseWriter, r *http.Request) {
+	model, ctx, err := h.resolve(w, r, core.ActionList)
+	if err != nil {
+		h.ResponseError(w, r, err, err.Error())
+		return
+	}
+
 	var inputData = new(dtos.GetListQueryParams)
 	if err := inputData.Bind(r); err != nil {
 		h.ResponseError(w, r, err, err.Error())
 		return
 	}
 
-	model, ok := r.Context().Value(constants.ModelKey).(*core.Model)
-	if !ok {
-		h.ResponseError(w, r, nil, "Model not found in context")
+	if err := h.authorize(ctx, model, nil); err != nil {
+		h.ResponseError(w, r, err, err.Error())
 		return
 	}
-	resData, total, err := h.Service.GetList(model, inputData)
+	if scoper, ok := h.Authorizer.(core.ListScoper); ok {
+		if scope := scoper.ListScope(ctx, core.SubjectFromContext(ctx), model); scope != nil {
+			ctx = context.WithValue(ctx, constants.ScopesKey, append(core.ScopesFromContext(ctx), scope))
+		}
+	}
+	resData, total, err := h.Service.GetList(ctx, model, inputData)
 	if err != nil {
 		h.ResponseError(w, r, err, err.Error())
 		return
 	}
 
+	logRows(ctx, len(resData))
+	if entry := core.RequestLogFromContext(ctx); entry != nil {
+		entry.Conditions = len(inputData.Filter.Columns())
+	}
 	h.ResponseGetList(w, r, resData, uint(total),
 		uint(inputData.Page), uint(inputData.PageSize))
 }
 
 func (h *Handler) GetListById(w http.ResponseWriter, r *http.Request) {
+	model, ctx, err := h.resolve(w, r, core.ActionDetail)
+	if err != nil {
+		h.ResponseError(w, r, err, err.Error())
+		return
+	}
 
-	model, ok := r.Context().Value(constants.ModelKey).(*core.Model)
-	if !ok {
-		h.ResponseError(w, r, nil, "Model not found in context")
+	id := r.PathValue("id")
+	if err := h.authorizeRecord(ctx, h.Service, model, id); err != nil {
+		h.ResponseError(w, r, err, err.Error())
 		return
 	}
-	id := chi.URLParam(r, "id")
-	res, err := h.Service.GetByID(model, id)
+	res, err := h.Service.GetByID(ctx, model, id)
 	if err != nil {
 		h.ResponseError(w, r, err, err.Error())
 		return
 	}
+	logRows(ctx, 1)
 	h.ResponseDetail(w, r, res)
 }
 func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	model, ctx, err := h.resolve(w, r, core.ActionCreate)
+	if err != nil {
+		h.ResponseError(w, r, err, err.Error())
+		return
+	}
+
 	// get params
 	var inputData = make(map[string]any)
 	if err := json.NewDecoder(r.Body).Decode(&inputData); err != nil {
+		err := errs.BadRequest(fmt.Sprintf("invalid request body: %v", err), err)
 		h.ResponseError(w, r, err, err.Error())
 		return
 	}
 
-	model, ok := r.Context().Value(constants.ModelKey).(*core.Model)
-	if !ok {
-		h.ResponseError(w, r, nil, "Model not found in context")
+	if err := h.authorize(ctx, model, &inputData); err != nil {
+		h.ResponseError(w, r, err, err.Error())
 		return
 	}
-	res, err := h.Service.Create(model, &inputData)
+	res, err := h.Service.Create(ctx, model, &inputData)
 	if err != nil {
 		h.ResponseError(w, r, err, err.Error())
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(res); err != nil {
-		h.ResponseError(w, r, err, err.Error())
-		return
+	if id, ok := recordID(model, *res); ok {
+		w.Header().Set("Location", path.Join(r.URL.Path, fmt.Sprint(id)))
+		if entry := core.RequestLogFromContext(ctx); entry != nil {
+			entry.ID = fmt.Sprint(id)
+		}
 	}
+	logRows(ctx, 1)
+	h.responseDetail(w, r, res, http.StatusCreated)
 }
 func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
-	var inputData = make(map[string]any)
-	if err := json.NewDecoder(r.Body).Decode(&inputData); err != nil {
+	model, ctx, err := h.resolve(w, r, core.ActionUpdate)
+	if err != nil {
 		h.ResponseError(w, r, err, err.Error())
 		return
 	}
 
-	model, ok := r.Context().Value(constants.ModelKey).(*core.Model)
-	if !ok {
-		h.ResponseError(w, r, nil, "Model not found in context")
+	var inputData = make(map[string]any)
+	if err := json.NewDecoder(r.Body).Decode(&inputData); err != nil {
+		err := errs.BadRequest(fmt.Sprintf("invalid request body: %v", err), err)
+		h.ResponseError(w, r, err, err.Error())
 		return
 	}
-	id := chi.URLParam(r, "id")
-	res, err := h.Service.Update(model, &inputData, id)
+
+	id := r.PathValue("id")
+	var res *map[string]any
+	err = h.authorized(ctx, model, id, func(service services.IService) error {
+		var err error
+		res, err = service.Update(ctx, model, &inputData, id)
+		return err
+	})
 	if err != nil {
 		h.ResponseError(w, r, err, err.Error())
 		return
 	}
+	logRows(ctx, 1)
 	h.ResponseDetail(w, r, res)
 }
 func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
-	model, ok := r.Context().Value(constants.ModelKey).(*core.Model)
-	if !ok {
-		h.ResponseError(w, r, nil, "Model not found in context")
+	model, ctx, err := h.resolve(w, r, core.ActionDelete)
+	if err != nil {
+		h.ResponseError(w, r, err, err.Error())
 		return
 	}
-	id := chi.URLParam(r, "id")
-	err := h.Service.Delete(model, id)
+
+	id := r.PathValue("id")
+	err = h.authorized(ctx, model, id, func(service services.IService) error {
+		return service.Delete(ctx, model, id)
+	})
 	if err != nil {
 		h.ResponseError(w, r, err, err.Error())
 		return
 	}
+	logRows(ctx, 1)
 	h.ResponseDetail(w, r, nil)
 }
 
-func (h *Handler) ResponseError(w http.ResponseWriter, r *http.Request,
-	err error, msgErr string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusInternalServerError)
-	if h.DTOError == nil {
-		http.Error(w, msgErr, http.StatusInternalServerError)
-		return
+// recordID returns the id of the record by the column of the primary key, else by its struct name
+// or its json name, as the records of the typed models are keyed by their json encoding
+func recordID(model *core.Model, record map[string]any) (any, bool) {
+	if id, ok := record[model.Meta.PrimaryKey()]; ok || model.Meta.PrimaryField == nil {
+		return id, ok
+	}
+	if id, ok := record[model.Meta.PrimaryField.Name]; ok {
+		return id, ok
+	}
+	id, ok := record[model.Meta.PrimaryField.JSONName]
+	return id, ok
+}
+
+// logRows sets the number of records of the operation in the log of the request
+func logRows(ctx context.Context, rows int) {
+	if entry := core.RequestLogFromContext(ctx); entry != nil {
+		entry.Rows = rows
+	}
+}
+
+// resolve returns the model of the request and the context to run the action,
+// the Allow header is set if the action is disabled for the model
+func (h *Handler) resolve(w http.ResponseWriter, r *http.Request, action core.Action) (*core.Model, context.Context, error) {
+	model, ok := r.Context().Value(constants.ModelKey).(*core.Model)
+	if !ok {
+		return nil, nil, errs.Internal("Model not found in context", nil)
+	}
+
+	if !model.Allows(action) {
+		setAllow(w, model, action.IsRecordAction())
+		return nil, nil, errs.MethodNotAllowed(fmt.Sprintf("%s is disabled for %s", action, model.Name), nil)
+	}
+
+	ctx, err := h.requestContext(r, action)
+	if err != nil {
+		return nil, nil, err
 	}
+	return model, ctx, nil
+}
 
-	if err := json.NewEncoder(w).Encode(h.DTOError(w, r, err, msgErr)); err != nil {
+// MethodNotAllowed responds to the methods which no action of the model is mounted on,
+// the Allow header lists the methods of the actions enabled for the model
+func (h *Handler) MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	model, ok := r.Context().Value(constants.ModelKey).(*core.Model)
+	if !ok {
+		err := errs.Internal("Model not found in context", nil)
 		h.ResponseError(w, r, err, err.Error())
 		return
 	}
+
+	setAllow(w, model, r.PathValue("id") != "")
+	err := errs.MethodNotAllowed(fmt.Sprintf("%s isn't allowed for %s", r.Method, model.Name), nil)
+	h.ResponseError(w, r, err, err.Error())
 }
 
-func (h *Handler) ResponseDetail(w http.ResponseWriter, r *http.Request,
-	ref any) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if h.DTOGetDetail == nil {
-		if err := json.NewEncoder(w).Encode(ref); err != nil {
-			h.ResponseError(w, r, err, err.Error())
+// setAllow sets the Allow header to the methods of the actions enabled for the model,
+// on the records or on the model itself
+func setAllow(w http.ResponseWriter, model *core.Model, recordAction bool) {
+	var methods []string
+	for _, allowed := range core.AllActions {
+		if allowed.IsRecordAction() == recordAction && model.Allows(allowed) {
+			methods = append(methods, allowed.Method())
 		}
-		return
 	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+}
 
-	if err := json.NewEncoder(w).Encode(h.DTOGetDetail(w, r, ref)); err != nil {
-		h.ResponseError(w, r, err, err.Error())
+// requestContext returns the context of the request with the action of the handler, the fields
+// requested by the client, and the tenant, the subject and the role resolved by the resolvers
+func (h *Handler) requestContext(r *http.Request, action core.Action) (context.Context, error) {
+	ctx := context.WithValue(r.Context(), constants.ActionKey, action)
+	if entry := core.RequestLogFromContext(ctx); entry != nil {
+		entry.Action = action
+		entry.ID = r.PathValue("id")
+	}
+	if fields := requestedFields(r); len(fields) > 0 {
+		ctx = context.WithValue(ctx, constants.FieldsKey, fields)
+	}
+	if h.TenantResolver != nil {
+		tenant, err := h.TenantResolver(r)
+		if err != nil {
+			return nil, forbidden(err)
+		}
+		if tenant != nil {
+			ctx = context.WithValue(ctx, constants.TenantKey, tenant)
+		}
+	}
+
+	if h.SubjectResolver != nil {
+		subject, err := h.SubjectResolver(r)
+		if err != nil {
+			return nil, forbidden(err)
+		}
+		ctx = context.WithValue(ctx, constants.SubjectKey, subject)
+	}
+
+	if h.RoleResolver != nil {
+		ctx = context.WithValue(ctx, constants.RoleKey, h.RoleResolver(ctx))
+	}
+	return ctx, nil
+}
+
+// requestedFields returns the fields of the fields query param
+// Example: ?fields=id,name,full_name
+func requestedFields(r *http.Request) []string {
+	var fields []string
+	for _, field := range strings.Split(r.URL.Query().Get("fields"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// authorize asks the Authorizer if the subject can do the action of the request on the record
+func (h *Handler) authorize(ctx context.Context, model *core.Model, record *map[string]any) error {
+	if h.Authorizer == nil {
+		return nil
+	}
+	err := h.Authorizer.Can(ctx, core.SubjectFromContext(ctx), model, core.ActionFromContext(ctx), record)
+	if err != nil {
+		return forbidden(err)
+	}
+	return nil
+}
+
+// authorizeRecord loads the record of id as it's stored to authorize the action of the request,
+// so the decision doesn't depend on the masked, computed or requested fields
+func (h *Handler) authorizeRecord(ctx context.Context, service services.IService, model *core.Model, id string) error {
+	if h.Authorizer == nil {
+		return nil
+	}
+	record, err := service.Find(ctx, model, id)
+	if err != nil {
+		return err
+	}
+	return h.authorize(ctx, model, record)
+}
+
+// authorized runs fn once the action of the request is authorized on the record of id,
+// the record is loaded inside the transaction of fn so it can't change before it's written
+func (h *Handler) authorized(ctx context.Context, model *core.Model, id string, fn func(service services.IService) error) error {
+	if h.Authorizer == nil {
+		return fn(h.Service)
+	}
+	return h.Service.Transaction(ctx, model, func(tx services.IService) error {
+		if err := h.authorizeRecord(ctx, tx, model, id); err != nil {
+			return err
+		}
+		return fn(tx)
+	})
+}
+
+// forbidden considers the errors which are not raised by the generator as forbidden errors
+func forbidden(err error) error {
+	if e := errs.As(err); e.Kind != errs.KindInternal {
+		return err
+	}
+	return errs.Forbidden("", err)
+}
+
+func (h *Handler) ResponseError(w http.ResponseWriter, r *http.Request,
+	err error, msgErr string) {
+	status := errs.StatusCode(err)
+	if entry := core.RequestLogFromContext(r.Context()); entry != nil {
+		entry.Err = err
+	}
+	dtoError := h.DTOError
+	if model, ok := r.Context().Value(constants.ModelKey).(*core.Model); ok && model.DTOError != nil {
+		dtoError = model.DTOError
+	}
+	if dtoError == nil {
+		dtoError = dtos.ProblemDetailsError
+	}
+
+	ref := dtoError(w, r, err, msgErr, status)
+	body, encodeErr := encodeJSON(ref)
+	if encodeErr != nil {
+		http.Error(w, encodeErr.Error(), http.StatusInternalServerError)
 		return
 	}
+
+	contentType := "application/json"
+	if _, ok := ref.(*dtos.ProblemDetails); ok {
+		contentType = dtos.ProblemContentType
+	}
+	writeBody(w, status, contentType, body)
+}
+
+func (h *Handler) ResponseDetail(w http.ResponseWriter, r *http.Request,
+	ref any) {
+	h.responseDetail(w, r, ref, http.StatusOK)
+}
+
+func (h *Handler) responseDetail(w http.ResponseWriter, r *http.Request,
+	ref any, status int) {
+	dtoGetDetail := h.DTOGetDetail
+	if model, ok := r.Context().Value(constants.ModelKey).(*core.Model); ok && model.DTOGetDetail != nil {
+		dtoGetDetail = model.DTOGetDetail
+	}
+	if dtoGetDetail != nil {
+		ref = dtoGetDetail(w, r, ref)
+	}
+	h.responseJSON(w, r, ref, status)
 }
 
 func (h *Handler) ResponseGetList(w http.ResponseWriter, r *http.Request,
 	ref any, total, page, pageSize uint) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if h.DTOGetList == nil {
-		if err := json.NewEncoder(w).Encode(ref); err != nil {
-			h.ResponseError(w, r, err, err.Error())
-		}
-		return
+	dtoGetList := h.DTOGetList
+	if model, ok := r.Context().Value(constants.ModelKey).(*core.Model); ok && model.DTOGetList != nil {
+		dtoGetList = model.DTOGetList
 	}
+	if dtoGetList != nil {
+		ref = dtoGetList(w, r, ref, total, page, pageSize)
+	}
+	h.responseJSON(w, r, ref, http.StatusOK)
+}
 
-	if err := json.NewEncoder(w).Encode(h.DTOGetList(w, r, ref, total, page, pageSize)); err != nil {
+// responseJSON encodes the body before writing the header,
+// so an encoding error can still be responded with its own status
+func (h *Handler) responseJSON(w http.ResponseWriter, r *http.Request,
+	ref any, status int) {
+	body, err := encodeJSON(ref)
+	if err != nil {
+		err := errs.Internal("", err)
 		h.ResponseError(w, r, err, err.Error())
 		return
 	}
+	writeBody(w, status, "application/json", body)
+}
+
+func encodeJSON(ref any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(ref); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeBody(w http.ResponseWriter, status int, contentType string, body []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	w.Write(body)
 }
@@ -0,0 +1,90 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// GormConfig configures the gorm logger
+type GormConfig struct {
+	// SlowThreshold is the duration above which the queries are logged as slow queries, zero disables it
+	SlowThreshold time.Duration
+	// LogLevel is the level of gorm, the failed queries are logged from gormlogger.Error,
+	// the slow queries from gormlogger.Warn and every query at debug level from gormlogger.Info
+	LogLevel gormlogger.LogLevel
+	// IgnoreRecordNotFoundError doesn't log the queries which didn't find their record
+	IgnoreRecordNotFoundError bool
+}
+
+type gormLogger struct {
+	logger *slog.Logger
+	config GormConfig
+}
+
+// NewGormLogger returns a gorm logger writing to logger, the queries are logged with their sql,
+// duration and number of rows instead of being printed to stdout
+// Example: gorm.Open(dialector, &gorm.Config{Logger: logging.NewGormLogger(slog.Default(), logging.GormConfig{
+// SlowThreshold: 200 * time.Millisecond, LogLevel: gormlogger.Warn})})
+func NewGormLogger(logger *slog.Logger, config GormConfig) gormlogger.Interface {
+	return &gormLogger{
+		logger: logger,
+		config: config,
+	}
+}
+
+func (l *gormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	config := l.config
+	config.LogLevel = level
+	return &gormLogger{logger: l.logger, config: config}
+}
+
+func (l *gormLogger) Info(ctx context.Context, msg string, data ...any) {
+	if l.config.LogLevel >= gormlogger.Info {
+		l.logger.InfoContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *gormLogger) Warn(ctx context.Context, msg string, data ...any) {
+	if l.config.LogLevel >= gormlogger.Warn {
+		l.logger.WarnContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *gormLogger) Error(ctx context.Context, msg string, data ...any) {
+	if l.config.LogLevel >= gormlogger.Error {
+		l.logger.ErrorContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Trace logs the query after it's run, by the level of the logger
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.config.LogLevel <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	attrs := func() []slog.Attr {
+		sql, rows := fc()
+		return []slog.Attr{
+			slog.String("sql", sql),
+			slog.Duration("duration", elapsed),
+			slog.Int64("rows", rows),
+		}
+	}
+	switch {
+	case err != nil && l.config.LogLevel >= gormlogger.Error &&
+		!(l.config.IgnoreRecordNotFoundError && errors.Is(err, gorm.ErrRecordNotFound)):
+		l.logger.LogAttrs(ctx, slog.LevelError, "gorm query failed", append(attrs(), slog.Any("error", err))...)
+	case l.config.SlowThreshold > 0 && elapsed > l.config.SlowThreshold && l.config.LogLevel >= gormlogger.Warn:
+		l.logger.LogAttrs(ctx, slog.LevelWarn, "gorm slow query",
+			append(attrs(), slog.Duration("threshold", l.config.SlowThreshold))...)
+	case l.config.LogLevel >= gormlogger.Info:
+		l.logger.LogAttrs(ctx, slog.LevelDebug, "gorm query", attrs()...)
+	}
+}
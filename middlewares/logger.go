@@ -0,0 +1,80 @@
+package middlewares
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	constants "github.com/duytacong24895/go-crud-generator/const"
+	"github.com/duytacong24895/go-crud-generator/core"
+	"github.com/duytacong24895/go-crud-generator/dtos"
+)
+
+// statusRecorder keeps the status code written by the handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the writer of the server
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// RequestLog returns the log of the request, it's added to the context of the request if it's not there yet,
+// the middlewares wrapping Logger use it to share the log with Logger
+func RequestLog(r *http.Request) (*core.RequestLog, *http.Request) {
+	if entry := core.RequestLogFromContext(r.Context()); entry != nil {
+		return entry, r
+	}
+	entry := &core.RequestLog{}
+	return entry, r.WithContext(context.WithValue(r.Context(), constants.RequestLogKey, entry))
+}
+
+// Logger logs every request of the models when it's done, with its model, operation, id, request id,
+// status, duration and number of rows. The server errors are logged at error level, the others, including
+// the requests cancelled by the client, at debug level, so the access logs are only written when the handler
+// of logger enables the debug level
+func Logger(logger *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			entry, r := RequestLog(r)
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("operation", string(entry.Action)),
+				slog.Int("status", recorder.status),
+				slog.Duration("duration", time.Since(start)),
+				slog.Int("rows", entry.Rows),
+			}
+			if model, ok := r.Context().Value(constants.ModelKey).(*core.Model); ok {
+				attrs = append(attrs, slog.String("model", model.Name))
+			}
+			if entry.ID != "" {
+				attrs = append(attrs, slog.String("id", entry.ID))
+			}
+			if requestID := r.Header.Get(dtos.RequestIDHeader); requestID != "" {
+				attrs = append(attrs, slog.String("request_id", requestID))
+			}
+			if entry.Err != nil && recorder.status >= http.StatusInternalServerError {
+				attrs = append(attrs, slog.Any("error", entry.Err))
+			}
+
+			level := slog.LevelDebug
+			if recorder.status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			logger.LogAttrs(r.Context(), level, "crud request", attrs...)
+		})
+	}
+}
@@ -2,23 +2,68 @@ package middlewares
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"time"
 
 	constants "github.com/duytacong24895/go-crud-generator/const"
 	"github.com/duytacong24895/go-crud-generator/core"
-	"github.com/duytacong24895/go-crud-generator/runtime"
-	"github.com/go-chi/chi/v5"
+	"github.com/duytacong24895/go-crud-generator/errs"
+	"github.com/duytacong24895/go-crud-generator/handler"
 )
 
-func VerifyModel(next http.Handler) http.Handler {
+// VerifyModel puts the model of the request, resolved from the resource of the url, into the context of the request,
+// the unknown resources are responded by h as not found
+func VerifyModel(h *handler.Handler) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var resource = r.PathValue("modelName")
+			var model, ok = h.Models.Resolve(resource)
+			if !ok {
+				err := errs.NotFound(fmt.Sprintf("model %s not found", resource), nil)
+				h.ResponseError(w, r, err, err.Error())
+				return
+			}
+			ctx := context.WithValue(r.Context(), constants.ModelKey, model)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ModelMiddlewares runs the middlewares registered for the model of the request
+func ModelMiddlewares(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		var inputModelname = chi.URLParam(r, "modelName")
-		var model, ok = core.Core{}.DetectModelInUse(runtime.GetListModels().List, inputModelname)
-		if !ok {
-			http.Error(w, "Model not found", http.StatusNotFound)
+		model, ok := r.Context().Value(constants.ModelKey).(*core.Model)
+		if !ok || len(model.Middlewares) == 0 {
+			next.ServeHTTP(w, r)
 			return
 		}
-		ctx := context.WithValue(r.Context(), constants.ModelKey, model)
-		next.ServeHTTP(w, r.WithContext(ctx))
+
+		handler := next
+		for i := len(model.Middlewares) - 1; i >= 0; i-- {
+			handler = model.Middlewares[i](handler)
+		}
+		handler.ServeHTTP(w, r)
 	})
 }
+
+// Timeout cancels the context of the request when the timeout of its model, or fallback for the models
+// without their own timeout, is exceeded. The requests are not limited when both are zero
+func Timeout(fallback time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := fallback
+			if model, ok := r.Context().Value(constants.ModelKey).(*core.Model); ok && model.QueryTimeout > 0 {
+				timeout = model.QueryTimeout
+			}
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
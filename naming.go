@@ -0,0 +1,32 @@
+package crud_generator
+
+import (
+	"strings"
+
+	"github.com/duytacong24895/go-crud-generator/core"
+	"gorm.io/gorm/schema"
+)
+
+// NamingStrategy returns the segment of the model in the urls,
+// the segments are resolved regardless of the case
+type NamingStrategy func(model *core.Model) string
+
+// StructName names the model by the name of its struct, Employee => Employee. It's the default strategy
+func StructName(model *core.Model) string {
+	return model.Name
+}
+
+// Plural names the model by the plural of the name of its struct, OrderItem => OrderItems
+func Plural(model *core.Model) string {
+	return schema.NamingStrategy{NoLowerCase: true}.TableName(model.Name)
+}
+
+// SnakeCase names the model by its gorm table name, OrderItem => order_items
+func SnakeCase(model *core.Model) string {
+	return core.Core{}.ExactTableGorm(model.Ref)
+}
+
+// KebabCase names the model by its gorm table name with dashes, OrderItem => order-items
+func KebabCase(model *core.Model) string {
+	return strings.ReplaceAll(SnakeCase(model), "_", "-")
+}
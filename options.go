@@ -0,0 +1,128 @@
+package crud_generator
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/duytacong24895/go-crud-generator/core"
+	"github.com/duytacong24895/go-crud-generator/repositories"
+)
+
+// The actions generated for each model
+const (
+	List   = core.ActionList
+	Detail = core.ActionDetail
+	Create = core.ActionCreate
+	Update = core.ActionUpdate
+	Delete = core.ActionDelete
+)
+
+// DefaultBasePath is the path which the routes are mounted on when WithBasePath isn't used
+const DefaultBasePath = "/crud"
+
+// GeneratorOption configures the generator when it's created
+type GeneratorOption func(generator *crudGenerator)
+
+// WithBasePath mounts the routes on basePath instead of /crud
+// Example: WithBasePath("/api/v1")
+func WithBasePath(basePath string) GeneratorOption {
+	return func(generator *crudGenerator) {
+		generator.basePath = "/" + strings.Trim(basePath, "/")
+	}
+}
+
+// WithNamingStrategy sets the strategy naming the segments of the models in the urls,
+// the models registered with Alias keep their alias
+func WithNamingStrategy(naming NamingStrategy) GeneratorOption {
+	return func(generator *crudGenerator) {
+		generator.naming = naming
+	}
+}
+
+// WithDefaultRepository sets the repository of the models without their own repository instead of the gorm one
+// Example: NewCRUDGenerator(r, nil, WithDefaultRepository(repositories.NewMemoryRepository()))
+func WithDefaultRepository(repository repositories.IRepository) GeneratorOption {
+	return func(generator *crudGenerator) {
+		generator.repositories.SetDefault(repository)
+	}
+}
+
+// WithQueryTimeout sets the deadline of the requests of the models without their own timeout,
+// the requests which exceed it are cancelled and return 504 Gateway Timeout
+// Example: WithQueryTimeout(5 * time.Second)
+func WithQueryTimeout(timeout time.Duration) GeneratorOption {
+	return func(generator *crudGenerator) {
+		generator.queryTimeout = timeout
+	}
+}
+
+// WithLogger sets the logger of the generator instead of slog.Default(), every request of the models
+// is logged at debug level with its model, operation, id, duration and rows, the server errors at error level
+func WithLogger(logger *slog.Logger) GeneratorOption {
+	return func(generator *crudGenerator) {
+		generator.logger = logger
+	}
+}
+
+// Instrumentation observes the requests of the models and the calls of their repositories,
+// e.g. the tracing of the otel module of the generator
+type Instrumentation interface {
+	// Middleware wraps the requests of the models around the logger, once their model is resolved
+	Middleware(next http.Handler) http.Handler
+	// Repository wraps the repository of the service, which dispatches the calls to the repository of each model
+	Repository(repository repositories.IRepository) repositories.IRepository
+}
+
+// WithInstrumentation adds instrumentation to the generator, the instrumentations added first
+// wrap the others
+// Example: WithInstrumentation(crudotel.NewTracing(otel.GetTracerProvider()))
+func WithInstrumentation(instrumentation Instrumentation) GeneratorOption {
+	return func(generator *crudGenerator) {
+		generator.instrumentations = append(generator.instrumentations, instrumentation)
+	}
+}
+
+// ModelOption configures a model when it's registered
+type ModelOption func(model *core.Model)
+
+// Only enables only the actions for the model,
+// the routes of the other actions return 405 Method Not Allowed
+func Only(actions ...core.Action) ModelOption {
+	return func(model *core.Model) {
+		model.Actions = actions
+	}
+}
+
+// Alias sets the segment of the model in the urls, instead of the one of the naming strategy
+// Example: Alias("staff") mounts the model on /crud/staff
+func Alias(resource string) ModelOption {
+	return func(model *core.Model) {
+		model.Resource = resource
+	}
+}
+
+// ReadOnly enables only the get list and get detail actions for the model
+func ReadOnly() ModelOption {
+	return Only(List, Detail)
+}
+
+// QueryTimeout sets the deadline of the requests of the model, instead of the one of WithQueryTimeout
+func QueryTimeout(timeout time.Duration) ModelOption {
+	return func(model *core.Model) {
+		model.QueryTimeout = timeout
+	}
+}
+
+// ComputedOption configures a computed field with an expression when it's registered
+type ComputedOption func(field *core.ComputedField)
+
+// ExprType casts the expression to sqlType, the filters on the expression compare its values as this type,
+// otherwise they are compared as texts by the databases which don't infer it, like sqlite
+// Example: RegisterComputedExpr(&User{}, "name_length", "length(name)", ExprType("INTEGER"))
+func ExprType(sqlType string) ComputedOption {
+	return func(field *core.ComputedField) {
+		field.Type = sqlType
+	}
+}
@@ -0,0 +1,24 @@
+module github.com/duytacong24895/go-crud-generator/otel
+
+go 1.24.1
+
+require (
+	github.com/duytacong24895/go-crud-generator v0.0.0-20241022120000-abcdef123456
+	go.opentelemetry.io/otel v1.38.0
+	go.opentelemetry.io/otel/trace v1.38.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.3 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/jinzhu/inflection v1.0.0 // indirect
+	github.com/jinzhu/now v1.1.5 // indirect
+	go.etcd.io/bbolt v1.4.3 // indirect
+	go.opentelemetry.io/auto/sdk v1.1.0 // indirect
+	go.opentelemetry.io/otel/metric v1.38.0 // indirect
+	golang.org/x/sys v0.31.0 // indirect
+	golang.org/x/text v0.23.0 // indirect
+	gorm.io/gorm v1.30.0 // indirect
+)
+
+replace github.com/duytacong24895/go-crud-generator => ../
@@ -0,0 +1,84 @@
+package otel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/duytacong24895/go-crud-generator/core"
+	"github.com/duytacong24895/go-crud-generator/errs"
+	"github.com/duytacong24895/go-crud-generator/repositories"
+)
+
+// tracedRepository runs every call of repository in a span
+type tracedRepository struct {
+	repository repositories.IRepository
+	tracer     trace.Tracer
+}
+
+func (r *tracedRepository) start(ctx context.Context, operation string, model *core.Model) (context.Context, trace.Span) {
+	return r.tracer.Start(ctx, "repository."+operation, trace.WithAttributes(
+		attribute.String("crud.model", model.Name),
+	))
+}
+
+// end ends the span, the errors which are not caused by the client are errors of the span
+func end(span trace.Span, err error) {
+	if err != nil && errs.StatusCode(err) >= 500 {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (r *tracedRepository) GetList(ctx context.Context, model *core.Model, page int, pageSize int,
+	filter core.IFilter, order_by string) ([]*map[string]any, int64, error) {
+	ctx, span := r.start(ctx, "GetList", model)
+	entities, total, err := r.repository.GetList(ctx, model, page, pageSize, filter, order_by)
+	span.SetAttributes(
+		attribute.Int("crud.result.count", len(entities)),
+		attribute.Int64("crud.result.total", total),
+	)
+	end(span, err)
+	return entities, total, err
+}
+
+func (r *tracedRepository) Create(ctx context.Context, model *core.Model, inputData *map[string]any) (*map[string]any, error) {
+	ctx, span := r.start(ctx, "Create", model)
+	entity, err := r.repository.Create(ctx, model, inputData)
+	end(span, err)
+	return entity, err
+}
+
+func (r *tracedRepository) GetByID(ctx context.Context, model *core.Model, id string) (*map[string]any, error) {
+	ctx, span := r.start(ctx, "GetByID", model)
+	span.SetAttributes(attribute.String("crud.id", id))
+	entity, err := r.repository.GetByID(ctx, model, id)
+	end(span, err)
+	return entity, err
+}
+
+func (r *tracedRepository) Update(ctx context.Context, model *core.Model, inputData *map[string]any, id string) (*map[string]any, error) {
+	ctx, span := r.start(ctx, "Update", model)
+	span.SetAttributes(attribute.String("crud.id", id))
+	entity, err := r.repository.Update(ctx, model, inputData, id)
+	end(span, err)
+	return entity, err
+}
+
+func (r *tracedRepository) Delete(ctx context.Context, model *core.Model, id string) error {
+	ctx, span := r.start(ctx, "Delete", model)
+	span.SetAttributes(attribute.String("crud.id", id))
+	err := r.repository.Delete(ctx, model, id)
+	end(span, err)
+	return err
+}
+
+// Transaction isn't a span, the calls of the repository of the transaction are traced
+func (r *tracedRepository) Transaction(ctx context.Context, model *core.Model, fn func(repo repositories.IRepository) error) error {
+	return r.repository.Transaction(ctx, model, func(repo repositories.IRepository) error {
+		return fn(&tracedRepository{repository: repo, tracer: r.tracer})
+	})
+}
@@ -0,0 +1,128 @@
+// Package otel traces the requests of the CRUD generator and the calls of its repositories with OpenTelemetry
+package otel
+
+import (
+	"net/http"
+
+	otelapi "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	crud_generator "github.com/duytacong24895/go-crud-generator"
+	constants "github.com/duytacong24895/go-crud-generator/const"
+	"github.com/duytacong24895/go-crud-generator/core"
+	"github.com/duytacong24895/go-crud-generator/middlewares"
+	"github.com/duytacong24895/go-crud-generator/repositories"
+)
+
+// TracerName is the name of the tracer of the generator
+const TracerName = "github.com/duytacong24895/go-crud-generator"
+
+// Option configures the tracing when it's created
+type Option func(tracing *Tracing)
+
+// WithPropagator sets the propagator extracting the trace context of the requests from their headers,
+// instead of the global propagator of otel
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(tracing *Tracing) {
+		tracing.propagator = propagator
+	}
+}
+
+// Tracing is the instrumentation tracing the requests of the models, every request gets a span with
+// the model, the operation and the number of rows, and the calls of the repositories get child spans
+type Tracing struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// NewTracing returns the tracing of the generator with a tracer of provider
+// Example: crud_generator.WithInstrumentation(otel.NewTracing(provider, otel.WithPropagator(propagation.TraceContext{})))
+func NewTracing(provider trace.TracerProvider, opts ...Option) *Tracing {
+	tracing := &Tracing{tracer: provider.Tracer(TracerName)}
+	for _, opt := range opts {
+		opt(tracing)
+	}
+	return tracing
+}
+
+// WithTracerProvider traces the requests of the generator with a tracer of provider, read NewTracing
+// Example: crud_generator.NewCRUDGenerator(r, db, otel.WithTracerProvider(otelapi.GetTracerProvider()))
+func WithTracerProvider(provider trace.TracerProvider, opts ...Option) crud_generator.GeneratorOption {
+	return crud_generator.WithInstrumentation(NewTracing(provider, opts...))
+}
+
+// Middleware starts a span for every request of the models, as the child of the trace context extracted from
+// the headers by the propagator. The span is named after the model and the operation, and has their attributes
+// with the id of the record, the number of conditions of the filter and the number of rows
+func (t *Tracing) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		propagator := t.propagator
+		if propagator == nil {
+			propagator = otelapi.GetTextMapPropagator()
+		}
+		ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := t.tracer.Start(ctx, "crud "+r.Method, trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+			))
+		defer span.End()
+
+		entry, r := middlewares.RequestLog(r.WithContext(ctx))
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		attrs := []attribute.KeyValue{
+			attribute.String("crud.operation", string(entry.Action)),
+			attribute.Int("crud.result.count", entry.Rows),
+			attribute.Int("http.response.status_code", recorder.status),
+		}
+		if model, ok := r.Context().Value(constants.ModelKey).(*core.Model); ok {
+			span.SetName("crud " + string(entry.Action) + " " + model.Name)
+			attrs = append(attrs, attribute.String("crud.model", model.Name))
+		}
+		if entry.ID != "" {
+			attrs = append(attrs, attribute.String("crud.id", entry.ID))
+		}
+		if entry.Action == core.ActionList {
+			attrs = append(attrs, attribute.Int("crud.filter.conditions", entry.Conditions))
+		}
+		span.SetAttributes(attrs...)
+
+		// As for the http servers, only the server errors are errors of the span
+		if recorder.status >= http.StatusInternalServerError {
+			if entry.Err != nil {
+				span.RecordError(entry.Err)
+			}
+			span.SetStatus(codes.Error, http.StatusText(recorder.status))
+		}
+	})
+}
+
+// Repository returns a repository which runs every call of repository in a span,
+// they are the children of the span of the request
+func (t *Tracing) Repository(repository repositories.IRepository) repositories.IRepository {
+	return &tracedRepository{
+		repository: repository,
+		tracer:     t.tracer,
+	}
+}
+
+// statusRecorder keeps the status code written by the handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the writer of the server
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
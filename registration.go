@@ -0,0 +1,59 @@
+package crud_generator
+
+import (
+	"net/http"
+
+	"github.com/duytacong24895/go-crud-generator/core"
+	"github.com/duytacong24895/go-crud-generator/repositories"
+)
+
+// IModelRegistration configures the model which was just registered,
+// the global middlewares and DTOs are used when the model doesn't set its own
+type IModelRegistration interface {
+	ICRUDGenerator
+	// WithMiddleware adds a middleware run after the global middlewares, only for the model
+	WithMiddleware(middleware func(next http.Handler) http.Handler) IModelRegistration
+	WithDTOGetDetail(func(w http.ResponseWriter, r *http.Request, ref any) any) IModelRegistration
+	WithDTOGetList(func(w http.ResponseWriter, r *http.Request, ref any, total, page, pageSize uint) any) IModelRegistration
+	WithDTOError(func(w http.ResponseWriter, r *http.Request, err error, errMsg string, status int) any) IModelRegistration
+	WithOptions(opts ...ModelOption) IModelRegistration
+	// WithRepository sets the repository of the model instead of the gorm one
+	WithRepository(repository repositories.IRepository) IModelRegistration
+}
+
+type modelRegistration struct {
+	*crudGenerator
+	model *core.Model
+}
+
+func (m *modelRegistration) WithMiddleware(middleware func(next http.Handler) http.Handler) IModelRegistration {
+	m.model.Middlewares = append(m.model.Middlewares, middleware)
+	return m
+}
+
+func (m *modelRegistration) WithDTOGetDetail(returndto func(w http.ResponseWriter, r *http.Request, ref any) any) IModelRegistration {
+	m.model.DTOGetDetail = returndto
+	return m
+}
+
+func (m *modelRegistration) WithDTOGetList(returndto func(w http.ResponseWriter, r *http.Request, ref any, total, page, pageSize uint) any) IModelRegistration {
+	m.model.DTOGetList = returndto
+	return m
+}
+
+func (m *modelRegistration) WithDTOError(returndto func(w http.ResponseWriter, r *http.Request, err error, errMsg string, status int) any) IModelRegistration {
+	m.model.DTOError = returndto
+	return m
+}
+
+func (m *modelRegistration) WithOptions(opts ...ModelOption) IModelRegistration {
+	for _, opt := range opts {
+		opt(m.model)
+	}
+	return m
+}
+
+func (m *modelRegistration) WithRepository(repository repositories.IRepository) IModelRegistration {
+	m.repositories.Set(m.model.Name, repository)
+	return m
+}
@@ -0,0 +1,685 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/duytacong24895/go-crud-generator/core"
+	"github.com/duytacong24895/go-crud-generator/errs"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// recordsBucket keeps the records of a model as json by their primary key
+	recordsBucket = []byte("records")
+	// indexesBucket keeps a bucket per indexed column, with a bucket of the ids per value
+	indexesBucket = []byte("indexes")
+)
+
+// valueKind is the kind of the values of a column, it decides how the values are stored and indexed
+type valueKind int
+
+const (
+	kindOther valueKind = iota
+	kindString
+	kindNumber
+	kindBool
+	kindTime
+)
+
+type boltRepository struct {
+	db *bolt.DB
+	// tx is set for the repository of a transaction
+	tx *bolt.Tx
+}
+
+// NewBoltRepository returns a repository keeping the records in a bbolt file, for the small deployments
+// without a database server. The records of a model are stored as json in the bucket of the model, the
+// fields with the tag `crud_generator:"index"` get a secondary index which is used by the filters when it can.
+// The scopes and the computed fields with an expression are not supported
+func NewBoltRepository(db *bolt.DB) IRepository {
+	return &boltRepository{
+		db: db,
+	}
+}
+
+func (r *boltRepository) view(fn func(tx *bolt.Tx) error) error {
+	if r.tx != nil {
+		return fn(r.tx)
+	}
+	return r.db.View(fn)
+}
+
+func (r *boltRepository) update(fn func(tx *bolt.Tx) error) error {
+	if r.tx != nil {
+		return fn(r.tx)
+	}
+	return r.db.Update(fn)
+}
+
+// Transaction runs fn in a writable bbolt transaction, the changes are rolled back if fn returns an error
+func (r *boltRepository) Transaction(ctx context.Context, model *core.Model, fn func(repo IRepository) error) error {
+	if r.tx != nil {
+		// The repository is already bound to a transaction
+		return fn(r)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return fn(&boltRepository{db: r.db, tx: tx})
+	})
+}
+
+// kindOf returns the kind of the values of the field by its type in the struct of the model
+func kindOf(model *core.Model, field *core.ModelField) valueKind {
+	structField, ok := reflect.TypeOf(model.Ref).Elem().FieldByName(field.Name)
+	if !ok {
+		return kindOther
+	}
+	fieldType := structField.Type
+	if fieldType.Kind() == reflect.Pointer {
+		fieldType = fieldType.Elem()
+	}
+	if fieldType == reflect.TypeOf(time.Time{}) || fieldType.ConvertibleTo(reflect.TypeOf(sql.NullTime{})) {
+		return kindTime
+	}
+
+	switch fieldType.Kind() {
+	case reflect.String:
+		return kindString
+	case reflect.Bool:
+		return kindBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return kindNumber
+	}
+	return kindOther
+}
+
+// normalize converts the values of the record to the kinds of their columns, so the records are
+// compared and indexed the same way whatever the client sent
+func normalize(model *core.Model, record map[string]any) error {
+	for _, field := range model.Meta.Fields {
+		value := record[field.DBName]
+		if value == nil {
+			continue
+		}
+
+		invalid := errs.Validation(fmt.Sprintf("invalid value of %s: %v", field.DBName, value), nil).
+			WithField(field.DBName, fmt.Sprintf("%v isn't a valid value of %s", value, field.DBName))
+		switch kindOf(model, field) {
+		case kindNumber:
+			number := fmt.Sprint(value)
+			if _, err := strconv.ParseFloat(number, 64); err != nil {
+				return invalid
+			}
+			record[field.DBName] = json.Number(number)
+		case kindBool:
+			boolean, err := strconv.ParseBool(fmt.Sprint(value))
+			if err != nil {
+				return invalid
+			}
+			record[field.DBName] = boolean
+		case kindTime:
+			if t, ok := value.(*time.Time); ok {
+				value = *t
+			}
+			t, ok := core.ToTime(value)
+			if !ok {
+				return invalid
+			}
+			record[field.DBName] = t
+		}
+	}
+	return nil
+}
+
+// encode returns the json of the record
+func encode(record map[string]any) ([]byte, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, errs.Internal("", err)
+	}
+	return data, nil
+}
+
+// decode returns the record of the json, the numbers and the times are converted back to their kinds
+func decode(model *core.Model, data []byte) (map[string]any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var record map[string]any
+	if err := decoder.Decode(&record); err != nil {
+		return nil, errs.Internal("", err)
+	}
+
+	for key, value := range record {
+		if number, ok := value.(json.Number); ok {
+			if integer, err := number.Int64(); err == nil {
+				record[key] = integer
+			} else if float, err := number.Float64(); err == nil {
+				record[key] = float
+			}
+		}
+	}
+	for _, field := range model.Meta.Fields {
+		if value, ok := record[field.DBName].(string); ok && kindOf(model, field) == kindTime {
+			if t, ok := core.ToTime(value); ok {
+				record[field.DBName] = t
+			}
+		}
+	}
+	return record, nil
+}
+
+// indexKey returns the key of the value in the index, the keys of a column are sorted like its values.
+// ok is false when the value can't be indexed
+func indexKey(kind valueKind, value any) (key []byte, ok bool) {
+	// The keys are prefixed, so the empty strings can be indexed as well
+	key = []byte{'v'}
+	switch kind {
+	case kindString:
+		return append(key, fmt.Sprint(value)...), true
+	case kindNumber:
+		number, err := strconv.ParseFloat(fmt.Sprint(value), 64)
+		if err != nil {
+			return nil, false
+		}
+		bits := math.Float64bits(number)
+		if number >= 0 {
+			bits ^= 1 << 63
+		} else {
+			bits = ^bits
+		}
+		return binary.BigEndian.AppendUint64(key, bits), true
+	case kindBool:
+		boolean, err := strconv.ParseBool(fmt.Sprint(value))
+		if err != nil {
+			return nil, false
+		}
+		if boolean {
+			return append(key, 1), true
+		}
+		return append(key, 0), true
+	case kindTime:
+		t, ok := core.ToTime(value)
+		if !ok {
+			return nil, false
+		}
+		return binary.BigEndian.AppendUint64(key, uint64(t.UnixNano())^1<<63), true
+	}
+	return nil, false
+}
+
+// indexedField returns the indexed field of the column
+func indexedField(model *core.Model, column string) (*core.ModelField, bool) {
+	if field, ok := model.Meta.Field(column); ok && slices.Contains(model.Meta.IndexedFields, field) {
+		return field, true
+	}
+	return nil, false
+}
+
+// modelBucket returns the bucket of the model, it's created with its indexes when it doesn't exist,
+// and the indexes are rebuilt when the indexed fields of the model changed
+func modelBucket(tx *bolt.Tx, model *core.Model) (*bolt.Bucket, error) {
+	bucket, err := tx.CreateBucketIfNotExists([]byte(model.Name))
+	if err != nil {
+		return nil, err
+	}
+	records, err := bucket.CreateBucketIfNotExists(recordsBucket)
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := bucket.CreateBucketIfNotExists(indexesBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	var stale [][]byte
+	if err := indexes.ForEachBucket(func(name []byte) error {
+		if _, ok := indexedField(model, string(name)); !ok {
+			stale = append(stale, slices.Clone(name))
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	for _, name := range stale {
+		if err := indexes.DeleteBucket(name); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, field := range model.Meta.IndexedFields {
+		if indexes.Bucket([]byte(field.DBName)) != nil {
+			continue
+		}
+		index, err := indexes.CreateBucket([]byte(field.DBName))
+		if err != nil {
+			return nil, err
+		}
+		kind := kindOf(model, field)
+		if err := records.ForEach(func(id, data []byte) error {
+			record, err := decode(model, data)
+			if err != nil {
+				return err
+			}
+			return addIndex(index, kind, record[field.DBName], id)
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return bucket, nil
+}
+
+func addIndex(index *bolt.Bucket, kind valueKind, value any, id []byte) error {
+	if value == nil {
+		return nil
+	}
+	key, ok := indexKey(kind, value)
+	if !ok {
+		return nil
+	}
+	ids, err := index.CreateBucketIfNotExists(key)
+	if err != nil {
+		return err
+	}
+	return ids.Put(id, nil)
+}
+
+func removeIndex(index *bolt.Bucket, kind valueKind, value any, id []byte) error {
+	if value == nil {
+		return nil
+	}
+	key, ok := indexKey(kind, value)
+	if !ok {
+		return nil
+	}
+	ids := index.Bucket(key)
+	if ids == nil {
+		return nil
+	}
+	if err := ids.Delete(id); err != nil {
+		return err
+	}
+	if first, _ := ids.Cursor().First(); first == nil {
+		return index.DeleteBucket(key)
+	}
+	return nil
+}
+
+// put stores the record and updates the indexes, previous is the stored record of id if any
+func put(bucket *bolt.Bucket, model *core.Model, id []byte, previous, record map[string]any) error {
+	indexes := bucket.Bucket(indexesBucket)
+	for _, field := range model.Meta.IndexedFields {
+		index := indexes.Bucket([]byte(field.DBName))
+		kind := kindOf(model, field)
+		if previous != nil {
+			if err := removeIndex(index, kind, previous[field.DBName], id); err != nil {
+				return err
+			}
+		}
+		if record != nil {
+			if err := addIndex(index, kind, record[field.DBName], id); err != nil {
+				return err
+			}
+		}
+	}
+
+	records := bucket.Bucket(recordsBucket)
+	if record == nil {
+		return records.Delete(id)
+	}
+	data, err := encode(record)
+	if err != nil {
+		return err
+	}
+	return records.Put(id, data)
+}
+
+// lookup returns the ids of the records which can satisfy the condition by the indexes,
+// ok is false when the condition can't be answered by the indexes, the records must be scanned then
+func lookup(indexes *bolt.Bucket, model *core.Model, condition *core.Condition) (ids map[string]bool, ok bool, err error) {
+	if condition.IsBlock() {
+		if condition.Left == nil || condition.Right == nil {
+			return nil, false, nil
+		}
+		left, leftOk, err := lookup(indexes, model, condition.Left)
+		if err != nil {
+			return nil, false, err
+		}
+		right, rightOk, err := lookup(indexes, model, condition.Right)
+		if err != nil {
+			return nil, false, err
+		}
+		switch core.Operator(condition.Operator) {
+		case core.AndOperator:
+			switch {
+			case leftOk && rightOk:
+				maps.DeleteFunc(left, func(id string, _ bool) bool { return !right[id] })
+				return left, true, nil
+			case leftOk:
+				return left, true, nil
+			case rightOk:
+				return right, true, nil
+			}
+		case core.OrOperator:
+			if leftOk && rightOk {
+				maps.Copy(left, right)
+				return left, true, nil
+			}
+		}
+		return nil, false, nil
+	}
+
+	field, found := indexedField(model, condition.ColumnName)
+	if !found {
+		return nil, false, nil
+	}
+	index := indexes.Bucket([]byte(field.DBName))
+	operator, err := condition.SQLOperator()
+	if index == nil || err != nil {
+		return nil, false, nil
+	}
+	kind := kindOf(model, field)
+	if kind == kindString {
+		// The strings which look like numbers are compared as numbers by the filters, so only the
+		// other strings are looked up, and only by equality as the order of the index is the order of the bytes
+		if _, err := strconv.ParseFloat(condition.Value, 64); err != nil && operator == "=" {
+			key, _ := indexKey(kind, condition.Value)
+			ids, err := idsOf(index, key, key)
+			return ids, err == nil, err
+		}
+		return nil, false, nil
+	}
+
+	var from, to []byte
+	switch operator {
+	case "=":
+		from, found = indexKey(kind, condition.Value)
+		to = from
+	case ">", ">=":
+		from, found = indexKey(kind, condition.Value)
+	case "<", "<=":
+		to, found = indexKey(kind, condition.Value)
+	case "between":
+		values := strings.Split(condition.Value, core.SepOfBetween)
+		if len(values) != 2 {
+			return nil, false, nil
+		}
+		var fromOk, toOk bool
+		from, fromOk = indexKey(kind, values[0])
+		to, toOk = indexKey(kind, values[1])
+		found = fromOk && toOk
+	default:
+		return nil, false, nil
+	}
+	if !found {
+		return nil, false, nil
+	}
+	// The bounds are inclusive, the records out of the strict bounds are dropped by the match of the filter
+	ids, err = idsOf(index, from, to)
+	return ids, err == nil, err
+}
+
+// idsOf returns the ids of the values of the index between from and to, a nil bound is open
+func idsOf(index *bolt.Bucket, from, to []byte) (map[string]bool, error) {
+	ids := make(map[string]bool)
+	cursor := index.Cursor()
+	key, _ := cursor.First()
+	if from != nil {
+		key, _ = cursor.Seek(from)
+	}
+	for ; key != nil && (to == nil || bytes.Compare(key, to) <= 0); key, _ = cursor.Next() {
+		if err := index.Bucket(key).ForEach(func(id, _ []byte) error {
+			ids[string(id)] = true
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// present returns the record as it's returned by the gorm repository
+func (r *boltRepository) present(ctx context.Context, model *core.Model, record map[string]any) (*map[string]any, error) {
+	entity, err := typedRecord(ctx, model, record)
+	if err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+// find returns the record of id if it's visible
+func (r *boltRepository) find(ctx context.Context, bucket *bolt.Bucket, model *core.Model, id string) (map[string]any, error) {
+	if bucket == nil {
+		return nil, errs.NotFound("record not found", nil)
+	}
+	data := bucket.Bucket(recordsBucket).Get([]byte(id))
+	if data == nil {
+		return nil, errs.NotFound("record not found", nil)
+	}
+	record, err := decode(model, data)
+	if err != nil {
+		return nil, err
+	}
+	shown, err := visible(ctx, model, record)
+	if err != nil {
+		return nil, err
+	}
+	if !shown {
+		return nil, errs.NotFound("record not found", nil)
+	}
+	return record, nil
+}
+
+func (r *boltRepository) GetByID(ctx context.Context, model *core.Model, id string) (*map[string]any, error) {
+	var record map[string]any
+	if err := r.view(func(tx *bolt.Tx) error {
+		var err error
+		record, err = r.find(ctx, tx.Bucket([]byte(model.Name)), model, id)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return r.present(ctx, model, record)
+}
+
+func (r *boltRepository) GetList(ctx context.Context, model *core.Model, page int, pageSize int,
+	filter core.IFilter, order_by string) ([]*map[string]any, int64, error) {
+	if err := checkReadable(ctx, model, filter, order_by); err != nil {
+		return nil, 0, err
+	}
+
+	var records []map[string]any
+	err := r.view(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(model.Name))
+		if bucket == nil {
+			return nil
+		}
+
+		collect := func(data []byte) error {
+			record, err := decode(model, data)
+			if err != nil {
+				return err
+			}
+			shown, err := visible(ctx, model, record)
+			if err != nil || !shown {
+				return err
+			}
+			matched, err := filter.Match(record)
+			if err != nil {
+				return errs.BadRequest("", err)
+			}
+			if matched {
+				records = append(records, record)
+			}
+			return nil
+		}
+
+		stored := bucket.Bucket(recordsBucket)
+		if root := filter.Root(); root != nil {
+			ids, ok, err := lookup(bucket.Bucket(indexesBucket), model, root)
+			if err != nil {
+				return err
+			}
+			if ok {
+				for id := range ids {
+					if data := stored.Get([]byte(id)); data != nil {
+						if err := collect(data); err != nil {
+							return err
+						}
+					}
+				}
+				return nil
+			}
+		}
+		return stored.ForEach(func(_, data []byte) error {
+			return collect(data)
+		})
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// The keys are sorted as bytes, the records are sorted by their primary key first, as in a database
+	key := model.Meta.PrimaryKey()
+	slices.SortFunc(records, func(a, b map[string]any) int {
+		return core.CompareValues(a[key], b[key])
+	})
+	if err := sortRecords(records, order_by); err != nil {
+		return nil, 0, err
+	}
+
+	total := int64(len(records))
+	start := min(max((page-1)*pageSize, 0), len(records))
+	end := min(start+pageSize, len(records))
+	var result []*map[string]any
+	for _, record := range records[start:end] {
+		entity, err := r.present(ctx, model, record)
+		if err != nil {
+			return nil, 0, err
+		}
+		result = append(result, entity)
+	}
+	return result, total, nil
+}
+
+func (r *boltRepository) Create(ctx context.Context, model *core.Model, inputData *map[string]any) (*map[string]any, error) {
+	if err := typedPayload(ctx, model, inputData); err != nil {
+		return nil, err
+	}
+
+	if err := stampCreate(ctx, model, inputData); err != nil {
+		return nil, err
+	}
+
+	// The columns which are not sent are null, as in the database
+	record := make(map[string]any, len(model.Meta.Fields))
+	for _, field := range model.Meta.Fields {
+		record[field.DBName] = nil
+	}
+	assign(model, record, *inputData)
+
+	var id string
+	err := r.update(func(tx *bolt.Tx) error {
+		bucket, err := modelBucket(tx, model)
+		if err != nil {
+			return err
+		}
+
+		records := bucket.Bucket(recordsBucket)
+		key := model.Meta.PrimaryKey()
+		if record[key] == nil {
+			sequence, err := records.NextSequence()
+			if err != nil {
+				return err
+			}
+			record[key] = sequence
+		} else if sequence, err := strconv.ParseUint(fmt.Sprint(record[key]), 10, 64); err == nil && sequence > records.Sequence() {
+			if err := records.SetSequence(sequence); err != nil {
+				return err
+			}
+		}
+		if err := normalize(model, record); err != nil {
+			return err
+		}
+
+		id = fmt.Sprint(record[key])
+		if records.Get([]byte(id)) != nil {
+			return errs.Conflict("record already exists", nil)
+		}
+		return put(bucket, model, []byte(id), nil, record)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, model, id)
+}
+
+func (r *boltRepository) Update(ctx context.Context, model *core.Model, inputData *map[string]any, id string) (*map[string]any, error) {
+	if err := typedPayload(ctx, model, inputData); err != nil {
+		return nil, err
+	}
+
+	stampUpdate(model, inputData)
+	// The primary key is the key of the record in the bucket
+	if model.Meta.PrimaryField != nil {
+		removeField(model, inputData, model.Meta.PrimaryField)
+	}
+
+	err := r.update(func(tx *bolt.Tx) error {
+		bucket, err := modelBucket(tx, model)
+		if err != nil {
+			return err
+		}
+		previous, err := r.find(ctx, bucket, model, id)
+		if err != nil {
+			return err
+		}
+
+		record := maps.Clone(previous)
+		assign(model, record, *inputData)
+		if err := normalize(model, record); err != nil {
+			return err
+		}
+		return put(bucket, model, []byte(id), previous, record)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, model, id)
+}
+
+func (r *boltRepository) Delete(ctx context.Context, model *core.Model, id string) error {
+	return r.update(func(tx *bolt.Tx) error {
+		bucket, err := modelBucket(tx, model)
+		if err != nil {
+			return err
+		}
+		previous, err := r.find(ctx, bucket, model, id)
+		if err != nil {
+			return err
+		}
+
+		if model.Meta.SoftDeletedField != nil {
+			// Soft delete
+			record := maps.Clone(previous)
+			record[model.Meta.SoftDeletedField.DBName] = time.Now()
+			return put(bucket, model, []byte(id), previous, record)
+		}
+		return put(bucket, model, []byte(id), previous, nil)
+	})
+}
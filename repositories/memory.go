@@ -0,0 +1,307 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/duytacong24895/go-crud-generator/core"
+	"github.com/duytacong24895/go-crud-generator/errs"
+)
+
+// memoryStore keeps the records of every model by the name of the model
+type memoryStore struct {
+	mu     sync.Mutex
+	tables map[string]*memoryTable
+}
+
+type memoryTable struct {
+	// records are the records by their primary key, their fields are keyed by the column names
+	records map[string]map[string]any
+	// ids keeps the order of insertion
+	ids    []string
+	nextID int64
+}
+
+type memoryRepository struct {
+	store *memoryStore
+	// inTx is set for the repository of a transaction, the store is already locked by the transaction
+	inTx bool
+}
+
+// NewMemoryRepository returns a repository keeping the records in memory, for the tests and the prototypes.
+// The filters, the sorting, the pagination, the soft delete, the timestamps and the tenants behave like the
+// gorm repository, the scopes and the computed fields with an expression are not supported
+func NewMemoryRepository() IRepository {
+	return &memoryRepository{
+		store: &memoryStore{tables: make(map[string]*memoryTable)},
+	}
+}
+
+func (r *memoryRepository) lock() func() {
+	if r.inTx {
+		return func() {}
+	}
+	r.store.mu.Lock()
+	return r.store.mu.Unlock
+}
+
+func (r *memoryRepository) table(model *core.Model) *memoryTable {
+	table, ok := r.store.tables[model.Name]
+	if !ok {
+		table = &memoryTable{records: make(map[string]map[string]any)}
+		r.store.tables[model.Name] = table
+	}
+	return table
+}
+
+// Transaction runs fn with the store locked, the records are restored if fn returns an error
+func (r *memoryRepository) Transaction(ctx context.Context, model *core.Model, fn func(repo IRepository) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	unlock := r.lock()
+	defer unlock()
+
+	snapshot := make(map[string]*memoryTable, len(r.store.tables))
+	for name, table := range r.store.tables {
+		records := make(map[string]map[string]any, len(table.records))
+		for id, record := range table.records {
+			records[id] = maps.Clone(record)
+		}
+		snapshot[name] = &memoryTable{records: records, ids: slices.Clone(table.ids), nextID: table.nextID}
+	}
+
+	if err := fn(&memoryRepository{store: r.store, inTx: true}); err != nil {
+		r.store.tables = snapshot
+		return err
+	}
+	return nil
+}
+
+// visible reports whether the record can be read by the request, like the clauses of scoped,
+// it's used by the repositories which don't run sql
+func visible(ctx context.Context, model *core.Model, record map[string]any) (bool, error) {
+	if len(model.Scopes) > 0 || len(core.ScopesFromContext(ctx)) > 0 {
+		return false, errs.Internal("the scopes are not supported by this repository", nil)
+	}
+	if model.Meta.SoftDeletedField != nil && record[model.Meta.SoftDeletedField.DBName] != nil {
+		return false, nil
+	}
+	if model.Meta.TenantField != nil {
+		tenant, ok := core.TenantFromContext(ctx)
+		if !ok {
+			return false, errs.Forbidden("tenant is required", nil)
+		}
+		return core.CompareValues(record[model.Meta.TenantField.DBName], tenant) == 0, nil
+	}
+	return true, nil
+}
+
+// find returns the record of id if it's visible
+func (r *memoryRepository) find(ctx context.Context, model *core.Model, id string) (map[string]any, error) {
+	record, ok := r.table(model).records[id]
+	if !ok {
+		return nil, errs.NotFound("record not found", nil)
+	}
+	shown, err := visible(ctx, model, record)
+	if err != nil {
+		return nil, err
+	}
+	if !shown {
+		return nil, errs.NotFound("record not found", nil)
+	}
+	return record, nil
+}
+
+// present returns a copy of the record as it's returned by the gorm repository
+func (r *memoryRepository) present(ctx context.Context, model *core.Model, record map[string]any) (*map[string]any, error) {
+	entity, err := typedRecord(ctx, model, maps.Clone(record))
+	if err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+// assign sets the input data to the record by the column names, the unknown fields are ignored
+func assign(model *core.Model, record map[string]any, inputData map[string]any) {
+	for key, value := range inputData {
+		if field, ok := model.Meta.Field(key); ok {
+			record[field.DBName] = value
+		}
+	}
+}
+
+func (r *memoryRepository) GetByID(ctx context.Context, model *core.Model, id string) (*map[string]any, error) {
+	unlock := r.lock()
+	defer unlock()
+
+	record, err := r.find(ctx, model, id)
+	if err != nil {
+		return nil, err
+	}
+	return r.present(ctx, model, record)
+}
+
+func (r *memoryRepository) GetList(ctx context.Context, model *core.Model, page int, pageSize int,
+	filter core.IFilter, order_by string) ([]*map[string]any, int64, error) {
+	if err := checkReadable(ctx, model, filter, order_by); err != nil {
+		return nil, 0, err
+	}
+
+	unlock := r.lock()
+	defer unlock()
+
+	table := r.table(model)
+	var records []map[string]any
+	for _, id := range table.ids {
+		record := table.records[id]
+		shown, err := visible(ctx, model, record)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !shown {
+			continue
+		}
+		matched, err := filter.Match(record)
+		if err != nil {
+			return nil, 0, errs.BadRequest("", err)
+		}
+		if matched {
+			records = append(records, record)
+		}
+	}
+
+	if err := sortRecords(records, order_by); err != nil {
+		return nil, 0, err
+	}
+
+	total := int64(len(records))
+	start := min(max((page-1)*pageSize, 0), len(records))
+	end := min(start+pageSize, len(records))
+	var result []*map[string]any
+	for _, record := range records[start:end] {
+		entity, err := r.present(ctx, model, record)
+		if err != nil {
+			return nil, 0, err
+		}
+		result = append(result, entity)
+	}
+	return result, total, nil
+}
+
+// sortRecords sorts the records by the order_by clause, e.g. "age desc, name"
+func sortRecords(records []map[string]any, orderBy string) error {
+	type order struct {
+		column string
+		desc   bool
+	}
+	var orders []order
+	for _, clause := range strings.Split(orderBy, ",") {
+		parts := strings.Fields(clause)
+		switch {
+		case len(parts) == 0:
+			continue
+		case len(parts) > 2 || (len(parts) == 2 && !slices.Contains([]string{"asc", "desc"}, strings.ToLower(parts[1]))):
+			return errs.BadRequest(fmt.Sprintf("invalid order_by: %s", clause), nil)
+		}
+		orders = append(orders, order{column: parts[0], desc: len(parts) == 2 && strings.EqualFold(parts[1], "desc")})
+	}
+
+	slices.SortStableFunc(records, func(a, b map[string]any) int {
+		for _, order := range orders {
+			if result := core.CompareValues(a[order.column], b[order.column]); result != 0 {
+				if order.desc {
+					return -result
+				}
+				return result
+			}
+		}
+		return 0
+	})
+	return nil
+}
+
+func (r *memoryRepository) Create(ctx context.Context, model *core.Model, inputData *map[string]any) (*map[string]any, error) {
+	if err := typedPayload(ctx, model, inputData); err != nil {
+		return nil, err
+	}
+
+	if err := stampCreate(ctx, model, inputData); err != nil {
+		return nil, err
+	}
+
+	unlock := r.lock()
+	defer unlock()
+
+	// The columns which are not sent are null, as in the database
+	record := make(map[string]any, len(model.Meta.Fields))
+	for _, field := range model.Meta.Fields {
+		record[field.DBName] = nil
+	}
+	assign(model, record, *inputData)
+
+	table := r.table(model)
+	key := model.Meta.PrimaryKey()
+	if record[key] == nil {
+		table.nextID++
+		record[key] = table.nextID
+	} else if id, err := strconv.ParseInt(fmt.Sprint(record[key]), 10, 64); err == nil && id > table.nextID {
+		table.nextID = id
+	}
+
+	id := fmt.Sprint(record[key])
+	if _, ok := table.records[id]; ok {
+		return nil, errs.Conflict("record already exists", nil)
+	}
+	table.records[id] = record
+	table.ids = append(table.ids, id)
+	return r.present(ctx, model, record)
+}
+
+func (r *memoryRepository) Update(ctx context.Context, model *core.Model, inputData *map[string]any, id string) (*map[string]any, error) {
+	if err := typedPayload(ctx, model, inputData); err != nil {
+		return nil, err
+	}
+
+	stampUpdate(model, inputData)
+	// The primary key is the key of the record in the store
+	if model.Meta.PrimaryField != nil {
+		removeField(model, inputData, model.Meta.PrimaryField)
+	}
+
+	unlock := r.lock()
+	defer unlock()
+
+	record, err := r.find(ctx, model, id)
+	if err != nil {
+		return nil, err
+	}
+	assign(model, record, *inputData)
+	return r.present(ctx, model, record)
+}
+
+func (r *memoryRepository) Delete(ctx context.Context, model *core.Model, id string) error {
+	unlock := r.lock()
+	defer unlock()
+
+	record, err := r.find(ctx, model, id)
+	if err != nil {
+		return err
+	}
+	if model.Meta.SoftDeletedField != nil {
+		// Soft delete
+		record[model.Meta.SoftDeletedField.DBName] = time.Now()
+		return nil
+	}
+
+	table := r.table(model)
+	delete(table.records, id)
+	table.ids = slices.DeleteFunc(table.ids, func(existing string) bool { return existing == id })
+	return nil
+}
@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"context"
+	"maps"
+
+	"github.com/duytacong24895/go-crud-generator/core"
+)
+
+// ModelRepositories dispatches the operations of each model to the repository of the model,
+// the models without their own repository use the default one
+type ModelRepositories struct {
+	base   IRepository
+	models map[string]IRepository
+}
+
+func NewModelRepositories(base IRepository) *ModelRepositories {
+	return &ModelRepositories{
+		base:   base,
+		models: make(map[string]IRepository),
+	}
+}
+
+// SetDefault sets the repository of the models without their own repository
+func (r *ModelRepositories) SetDefault(repository IRepository) {
+	r.base = repository
+}
+
+// Set sets the repository of the model
+func (r *ModelRepositories) Set(model string, repository IRepository) {
+	r.models[model] = repository
+}
+
+func (r *ModelRepositories) of(model *core.Model) IRepository {
+	if repository, ok := r.models[model.Name]; ok {
+		return repository
+	}
+	return r.base
+}
+
+func (r *ModelRepositories) GetList(ctx context.Context, model *core.Model, page int, pageSize int,
+	filter core.IFilter, order_by string) ([]*map[string]any, int64, error) {
+	return r.of(model).GetList(ctx, model, page, pageSize, filter, order_by)
+}
+
+func (r *ModelRepositories) Create(ctx context.Context, model *core.Model, inputData *map[string]any) (*map[string]any, error) {
+	return r.of(model).Create(ctx, model, inputData)
+}
+
+func (r *ModelRepositories) GetByID(ctx context.Context, model *core.Model, id string) (*map[string]any, error) {
+	return r.of(model).GetByID(ctx, model, id)
+}
+
+func (r *ModelRepositories) Update(ctx context.Context, model *core.Model, inputData *map[string]any, id string) (*map[string]any, error) {
+	return r.of(model).Update(ctx, model, inputData, id)
+}
+
+func (r *ModelRepositories) Delete(ctx context.Context, model *core.Model, id string) error {
+	return r.of(model).Delete(ctx, model, id)
+}
+
+// Transaction runs fn in a transaction of the repository of the model, the repository given to fn
+// dispatches the model to the transaction, so its hooks and its actions are rolled back with the write
+func (r *ModelRepositories) Transaction(ctx context.Context, model *core.Model, fn func(repo IRepository) error) error {
+	return r.of(model).Transaction(ctx, model, func(tx IRepository) error {
+		bound := &ModelRepositories{base: r.base, models: maps.Clone(r.models)}
+		if _, ok := r.models[model.Name]; ok {
+			bound.models[model.Name] = tx
+		} else {
+			bound.base = tx
+		}
+		return fn(bound)
+	})
+}
@@ -1,20 +1,31 @@
 package repositories
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/duytacong24895/go-crud-generator/core"
+	"github.com/duytacong24895/go-crud-generator/errs"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
 type IRepository interface {
-	GetList(model *core.Model, page int, pageSize int,
+	GetList(ctx context.Context, model *core.Model, page int, pageSize int,
 		filter core.IFilter, order_by string) ([]*map[string]any, int64, error)
-	Create(model *core.Model, inputData *map[string]any) (*map[string]any, error)
-	GetByID(model *core.Model, id string) (*map[string]any, error)
-	Update(model *core.Model, inputData *map[string]any, id string) (*map[string]any, error)
-	Delete(model *core.Model, id string) error
+	Create(ctx context.Context, model *core.Model, inputData *map[string]any) (*map[string]any, error)
+	GetByID(ctx context.Context, model *core.Model, id string) (*map[string]any, error)
+	Update(ctx context.Context, model *core.Model, inputData *map[string]any, id string) (*map[string]any, error)
+	Delete(ctx context.Context, model *core.Model, id string) error
+	// Transaction runs fn with a repository bound to a transaction of the repository of model,
+	// the transaction is committed if fn returns nil
+	Transaction(ctx context.Context, model *core.Model, fn func(repo IRepository) error) error
 }
 
 type repository struct {
@@ -27,91 +38,338 @@ func NewRepository(db *gorm.DB) IRepository {
 	}
 }
 
-func (r *repository) Create(model *core.Model, inputData *map[string]any) (*map[string]any, error) {
-	now := time.Now()
-	if model.Meta.UpdatedAtField != nil {
-		(*inputData)[model.Meta.UpdatedAtField.Name] = now
+func (r *repository) Transaction(ctx context.Context, model *core.Model, fn func(repo IRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&repository{db: tx})
+	})
+}
+
+// visible applies the soft delete clause and the tenant of the request to statement
+func (r *repository) visible(ctx context.Context, model *core.Model, statement *gorm.DB) *gorm.DB {
+	if model.Meta.SoftDeletedField != nil {
+		statement = statement.Where(model.Meta.SoftDeletedField.DBName + " IS NULL")
 	}
+	if model.Meta.TenantField != nil {
+		tenant, ok := core.TenantFromContext(ctx)
+		if !ok {
+			statement.AddError(errs.Forbidden("tenant is required", nil))
+			return statement
+		}
+		statement = statement.Where(model.Meta.TenantField.DBName+" = ?", tenant)
+	}
+	return statement
+}
 
-	if model.Meta.CreatedAtField != nil {
-		(*inputData)[model.Meta.CreatedAtField.Name] = now
+// scoped applies the soft delete clause, the tenant and the scopes of the model and the request to statement
+func (r *repository) scoped(ctx context.Context, model *core.Model, statement *gorm.DB) *gorm.DB {
+	statement = r.visible(ctx, model, statement)
+	for _, scope := range model.Scopes {
+		statement = scope(ctx, statement)
+	}
+	for _, scope := range core.ScopesFromContext(ctx) {
+		statement = scope(ctx, statement)
+	}
+	return statement
+}
+
+// translateError maps the errors of gorm and the database to the errors of errs package
+func (r *repository) translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if translator, ok := r.db.Dialector.(gorm.ErrorTranslator); ok {
+		if translated := translator.Translate(err); translated != err {
+			err = errors.Join(translated, err)
+		}
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return errs.Timeout("the query timed out", err)
+	case errors.Is(err, context.Canceled):
+		return errs.Canceled("the request was canceled", err)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return errs.NotFound("record not found", err)
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return errs.Conflict("record already exists", err)
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return errs.Conflict("record violates a foreign key constraint", err)
+	}
+	return err
+}
+
+func (r *repository) Create(ctx context.Context, model *core.Model, inputData *map[string]any) (*map[string]any, error) {
+	if err := typedPayload(ctx, model, inputData); err != nil {
+		return nil, err
 	}
 
-	if err := r.db.Clauses(clause.Returning{}).Model(&model.Ref).Create(inputData).Error; err != nil {
+	if err := stampCreate(ctx, model, inputData); err != nil {
 		return nil, err
 	}
-	return inputData, nil
+
+	returning := clause.Returning{}
+	if model.Typed {
+		// The columns of a typed model can't be scanned into the map by their types, only the key is returned
+		returning.Columns = []clause.Column{{Name: model.Meta.PrimaryKey()}}
+	}
+	if err := r.db.WithContext(ctx).Clauses(returning).Model(&model.Ref).Create(inputData).Error; err != nil {
+		return nil, r.translateError(err)
+	}
+
+	// Gorm backfills the primary key into the input map, so we re-read the row
+	// to return the db defaults and generated values as well
+	id, ok := (*inputData)[model.Meta.PrimaryKey()]
+	if !ok && model.Meta.PrimaryField != nil {
+		id, ok = (*inputData)[model.Meta.PrimaryField.Name]
+	}
+	if !ok {
+		return inputData, nil
+	}
+	// The scopes limit the records which are read and changed, not the ones which are created,
+	// so the record is returned even if it's out of them
+	return r.take(ctx, model, r.visible(ctx, model, r.read(ctx, model).Where(model.Meta.PrimaryKey()+" = ?", id)))
+}
+
+func (r *repository) GetByID(ctx context.Context, model *core.Model, id string) (*map[string]any, error) {
+	return r.take(ctx, model, r.scoped(ctx, model, r.read(ctx, model).Where(model.Meta.PrimaryKey()+" = ?", id)))
 }
 
-func (r *repository) GetByID(model *core.Model, id string) (*map[string]any, error) {
+// take returns the record of statement with its computed fields
+func (r *repository) take(ctx context.Context, model *core.Model, statement *gorm.DB) (*map[string]any, error) {
 	var entity = make(map[string]any)
-	statement := r.db.Model(&model.Ref).Where("id = ?", id)
-	if model.Meta.SoftDeletedField != nil {
-		statement = statement.Where(model.Meta.SoftDeletedField.DBName + " IS NULL")
+	if err := selectComputed(model, statement).Take(&entity).Error; err != nil {
+		return nil, r.translateError(err)
 	}
-	if err := statement.First(&entity).Error; err != nil {
+	entity, err := typedRecord(ctx, model, entity)
+	if err != nil {
 		return nil, err
 	}
 	return &entity, nil
 }
 
-func (r *repository) GetList(model *core.Model, page int, pageSize int,
+func (r *repository) GetList(ctx context.Context, model *core.Model, page int, pageSize int,
 	filter core.IFilter, order_by string) ([]*map[string]any, int64, error) {
 	var entities = make([]map[string]any, 0)
-	var queryStatement *gorm.DB
-	if filter.IsEmpty() {
-		queryStatement = r.db.Model(&model.Ref)
-	} else {
-		var err error
-		queryStatement, err = filter.BuildQuery(r.db)
+	if err := checkReadable(ctx, model, filter, order_by); err != nil {
+		return nil, 0, err
+	}
+
+	queryStatement := r.read(ctx, model)
+	if !filter.IsEmpty() {
+		filter.MapColumns(func(column string) string {
+			if field, ok := model.ComputedField(column); ok && field.Expr != "" {
+				return field.SQL()
+			}
+			return column
+		})
+		filterStatement, err := filter.BuildQuery(r.db)
 		if err != nil {
 			return nil, 0, err
 		}
-		queryStatement = queryStatement.Model(&model.Ref)
+		// The filter is grouped, so its OR conditions don't bypass the scopes
+		queryStatement = queryStatement.Where(filterStatement)
 	}
 
 	if order_by != "" {
 		queryStatement = queryStatement.Order(order_by)
 	}
 
-	if model.Meta.SoftDeletedField != nil {
-		queryStatement = queryStatement.Where(model.Meta.SoftDeletedField.DBName + " IS NULL")
-	}
+	queryStatement = r.scoped(ctx, model, queryStatement)
 
 	var total int64
 	if err := queryStatement.Count(&total).Error; err != nil {
-		return nil, 0, err
+		return nil, 0, r.translateError(err)
 	}
 
-	if err := queryStatement.Debug().Offset((page - 1) * pageSize).Limit(pageSize).
+	if err := selectComputed(model, queryStatement).Offset((page - 1) * pageSize).Limit(pageSize).
 		Find(&entities).Error; err != nil {
-		return nil, 0, err
+		return nil, 0, r.translateError(err)
 	}
 
 	var result []*map[string]any
 	for i := range entities {
-		result = append(result, &entities[i])
+		entity, err := typedRecord(ctx, model, entities[i])
+		if err != nil {
+			return nil, 0, err
+		}
+		result = append(result, &entity)
 	}
 	return result, total, nil
 }
 
-func (r *repository) Update(model *core.Model, inputData *map[string]any, id string) (*map[string]any, error) {
-	if model.Meta.UpdatedAtField != nil {
-		// Soft delete
-		(*inputData)[model.Meta.UpdatedAtField.Name] = time.Now()
+func (r *repository) Update(ctx context.Context, model *core.Model, inputData *map[string]any, id string) (*map[string]any, error) {
+	if err := typedPayload(ctx, model, inputData); err != nil {
+		return nil, err
 	}
 
-	if err := r.db.Model(&model.Ref).Where("id = ?", id).Updates(&inputData).Error; err != nil {
-		return nil, err
+	stampUpdate(model, inputData)
+
+	statement := r.scoped(ctx, model, r.db.WithContext(ctx).Model(&model.Ref).Where(model.Meta.PrimaryKey()+" = ?", id))
+	if err := statement.Updates(&inputData).Error; err != nil {
+		return nil, r.translateError(err)
 	}
-	return inputData, nil
+	return r.GetByID(ctx, model, id)
 }
 
-func (r *repository) Delete(model *core.Model, id string) error {
+func (r *repository) Delete(ctx context.Context, model *core.Model, id string) error {
+	var result *gorm.DB
+	statement := r.scoped(ctx, model, r.db.WithContext(ctx).Model(&model.Ref).Where(model.Meta.PrimaryKey()+" = ?", id))
 	if model.Meta.SoftDeletedField != nil {
 		// Soft delete
-		return r.db.Model(&model.Ref).Where("id = ?", id).
-			Update(model.Meta.SoftDeletedField.Name, time.Now()).Error
+		result = statement.Update(model.Meta.SoftDeletedField.Name, time.Now())
+	} else {
+		result = statement.Delete(model.Ref)
+	}
+
+	if result.Error != nil {
+		return r.translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errs.NotFound("record not found", nil)
+	}
+	return nil
+}
+
+// stampCreate sets the create and update times and the tenant of the request into the input data of a create,
+// the tenant sent by the client is ignored
+func stampCreate(ctx context.Context, model *core.Model, inputData *map[string]any) error {
+	now := time.Now()
+	if model.Meta.UpdatedAtField != nil {
+		(*inputData)[model.Meta.UpdatedAtField.Name] = now
+	}
+	if model.Meta.CreatedAtField != nil {
+		(*inputData)[model.Meta.CreatedAtField.Name] = now
+	}
+	if model.Meta.TenantField != nil {
+		tenant, ok := core.TenantFromContext(ctx)
+		if !ok {
+			return errs.Forbidden("tenant is required", nil)
+		}
+		removeField(model, inputData, model.Meta.TenantField)
+		(*inputData)[model.Meta.TenantField.Name] = tenant
+	}
+	return nil
+}
+
+// stampUpdate sets the update time into the input data of an update, and removes its tenant
+// as the records can't be moved to another tenant
+func stampUpdate(model *core.Model, inputData *map[string]any) {
+	if model.Meta.UpdatedAtField != nil {
+		(*inputData)[model.Meta.UpdatedAtField.Name] = time.Now()
+	}
+	if model.Meta.TenantField != nil {
+		removeField(model, inputData, model.Meta.TenantField)
+	}
+}
+
+// removeField removes the keys of the input data which refer to the field
+func removeField(model *core.Model, inputData *map[string]any, field *core.ModelField) {
+	for _, key := range model.Meta.Keys(*inputData, field) {
+		delete(*inputData, key)
+	}
+}
+
+// checkReadable rejects the filters and the orders on the fields which the role of the request can't read,
+// otherwise the values of the fields could be guessed
+func checkReadable(ctx context.Context, model *core.Model, filter core.IFilter, orderBy string) error {
+	columns := filter.Columns()
+	for _, order := range strings.Split(orderBy, ",") {
+		if parts := strings.Fields(order); len(parts) > 0 {
+			columns = append(columns, parts[0])
+		}
+	}
+
+	unreadable := model.Meta.UnreadableFields(core.RoleFromContext(ctx))
+	for _, column := range columns {
+		if field, ok := model.Meta.Field(column); ok && slices.Contains(unreadable, field) {
+			return errs.Forbidden(fmt.Sprintf("you don't have permission to read %s", field.DBName), nil)
+		}
+	}
+	return nil
+}
+
+// read returns the statement reading the records of the model, the typed models are read without
+// their schema, so the raw columns are scanned into their struct by typedRecord
+func (r *repository) read(ctx context.Context, model *core.Model) *gorm.DB {
+	if model.Typed {
+		return r.db.WithContext(ctx).Table(core.Core{}.ExactTableGorm(model.Ref))
+	}
+	return r.db.WithContext(ctx).Model(&model.Ref)
+}
+
+// typedPayload replaces the input data of a typed model with the values of the columns of its struct,
+// so the json tags and the gorm serializers of the struct apply
+func typedPayload(ctx context.Context, model *core.Model, inputData *map[string]any) error {
+	if !model.Typed {
+		return nil
+	}
+
+	encoded := make(map[string]any, len(*inputData))
+	columns := make(map[string]any, len(*inputData))
+	var names []string
+	for key, value := range *inputData {
+		field, ok := model.Meta.Field(key)
+		if !ok || field.JSONName == "" {
+			// The data which can't be decoded into the struct is kept
+			columns[key] = value
+			continue
+		}
+		encoded[field.JSONName] = value
+		names = append(names, field.Name)
+	}
+
+	record := reflect.New(reflect.TypeOf(model.Ref).Elem()).Interface()
+	if err := (core.Core{}).MapToStruct(encoded, record); err != nil {
+		invalid := errs.Validation(fmt.Sprintf("invalid request body: %v", err), err)
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok && typeErr.Field != "" {
+			invalid.WithField(typeErr.Field, fmt.Sprintf("%s can't be a %s", typeErr.Field, typeErr.Value))
+		}
+		return invalid
+	}
+	values, err := core.Core{}.ColumnValues(ctx, record, names)
+	if err != nil {
+		return errs.Internal("", err)
+	}
+	for column, value := range values {
+		columns[column] = value
+	}
+	*inputData = columns
+	return nil
+}
+
+// typedRecord scans the record of a typed model into its struct and returns the json encoding
+// of the struct, the computed fields with an expression are kept
+func typedRecord(ctx context.Context, model *core.Model, entity map[string]any) (map[string]any, error) {
+	if !model.Typed {
+		return entity, nil
+	}
+
+	value, err := core.Core{}.ScanStruct(ctx, model.Ref, entity)
+	if err != nil {
+		return nil, errs.Internal("", err)
+	}
+	record, err := core.Core{}.StructToMap(value)
+	if err != nil {
+		return nil, errs.Internal("", err)
+	}
+	for _, field := range model.ComputedFields {
+		if value, ok := entity[field.Name]; ok && field.Expr != "" {
+			record[field.Name] = value
+		}
+	}
+	return record, nil
+}
+
+// selectComputed selects the computed fields with an expression together with the columns
+func selectComputed(model *core.Model, statement *gorm.DB) *gorm.DB {
+	var exprs []string
+	for _, field := range model.ComputedFields {
+		if field.Expr != "" {
+			exprs = append(exprs, fmt.Sprintf("%s AS %s", field.SQL(), field.Name))
+		}
+	}
+	if len(exprs) == 0 {
+		return statement
 	}
-	return r.db.Model(&model.Ref).Where("id = ?", id).Delete(model.Ref).Error
+	return statement.Select("*, " + strings.Join(exprs, ", "))
 }
@@ -0,0 +1,420 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/duytacong24895/go-crud-generator/core"
+	"github.com/duytacong24895/go-crud-generator/errs"
+)
+
+// Dialect is the database of the sql repository, it sets the style of the placeholders
+type Dialect string
+
+const (
+	// DialectSQLite uses the ? placeholders
+	DialectSQLite Dialect = "sqlite"
+	// DialectPostgres uses the $1 placeholders
+	DialectPostgres Dialect = "postgres"
+)
+
+// Placeholder returns the placeholder of the arg at position, counted from 1
+func (d Dialect) Placeholder(position int) string {
+	if d == DialectPostgres {
+		return "$" + strconv.Itoa(position)
+	}
+	return "?"
+}
+
+// sqlExecutor is implemented by *sql.DB and *sql.Tx
+type sqlExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+type sqlRepository struct {
+	db      *sql.DB
+	exec    sqlExecutor
+	dialect Dialect
+}
+
+// NewSQLRepository returns a repository running parameterised sql with database/sql instead of gorm,
+// the tables and the columns are read from the struct tags of the models.
+// The scopes of the models are gorm scopes, so they are not supported. The models are still parsed
+// with the gorm schema, so gorm stays a dependency even if no query is run by gorm
+func NewSQLRepository(db *sql.DB, dialect Dialect) IRepository {
+	return &sqlRepository{
+		db:      db,
+		exec:    db,
+		dialect: dialect,
+	}
+}
+
+func (r *sqlRepository) Transaction(ctx context.Context, model *core.Model, fn func(repo IRepository) error) error {
+	if r.exec != r.db {
+		// The repository is already bound to a transaction
+		return fn(r)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return r.translateError(err)
+	}
+	if err := fn(&sqlRepository{db: r.db, exec: tx, dialect: r.dialect}); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return r.translateError(tx.Commit())
+}
+
+// translateError maps the errors of database/sql and the drivers to the errors of errs package
+func (r *sqlRepository) translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	message := err.Error()
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return errs.Timeout("the query timed out", err)
+	case errors.Is(err, context.Canceled):
+		return errs.Canceled("the request was canceled", err)
+	case errors.Is(err, sql.ErrNoRows):
+		return errs.NotFound("record not found", err)
+	case strings.Contains(message, "UNIQUE constraint failed"),
+		strings.Contains(message, "duplicate key value violates unique constraint"):
+		return errs.Conflict("record already exists", err)
+	case strings.Contains(message, "FOREIGN KEY constraint failed"),
+		strings.Contains(message, "violates foreign key constraint"):
+		return errs.Conflict("record violates a foreign key constraint", err)
+	}
+	return err
+}
+
+func quote(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+// sqlStatement is the statement being built, the placeholders are numbered by the position of the args
+type sqlStatement struct {
+	dialect    Dialect
+	conditions []string
+	args       []any
+}
+
+func (s *sqlStatement) bind(value any) string {
+	s.args = append(s.args, value)
+	return s.dialect.Placeholder(len(s.args))
+}
+
+func (s *sqlStatement) where() string {
+	if len(s.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(s.conditions, " AND ")
+}
+
+// scoped adds the soft delete clause and the tenant of the request to the statement, like the gorm repository
+func (r *sqlRepository) scoped(ctx context.Context, model *core.Model, statement *sqlStatement) error {
+	if len(model.Scopes) > 0 || len(core.ScopesFromContext(ctx)) > 0 {
+		return errs.Internal("the scopes are not supported by the sql repository", nil)
+	}
+
+	if model.Meta.SoftDeletedField != nil {
+		statement.conditions = append(statement.conditions, quote(model.Meta.SoftDeletedField.DBName)+" IS NULL")
+	}
+	if model.Meta.TenantField != nil {
+		tenant, ok := core.TenantFromContext(ctx)
+		if !ok {
+			return errs.Forbidden("tenant is required", nil)
+		}
+		statement.conditions = append(statement.conditions, quote(model.Meta.TenantField.DBName)+" = "+statement.bind(tenant))
+	}
+	return nil
+}
+
+// column returns the quoted column of name, or the expression of the computed field of name
+func column(model *core.Model, name string) (string, error) {
+	if field, ok := model.Meta.Field(name); ok {
+		return quote(field.DBName), nil
+	}
+	if field, ok := model.ComputedField(name); ok && field.Expr != "" {
+		return field.SQL(), nil
+	}
+	return "", errs.BadRequest(fmt.Sprintf("unknown column: %s", name), nil)
+}
+
+// selectColumns returns the columns of the select, the computed fields with an expression are selected as well
+func selectColumns(model *core.Model) string {
+	columns := []string{"*"}
+	for _, field := range model.ComputedFields {
+		if field.Expr != "" {
+			columns = append(columns, fmt.Sprintf("%s AS %s", field.SQL(), quote(field.Name)))
+		}
+	}
+	return strings.Join(columns, ", ")
+}
+
+// query runs the query and scans the rows into maps by the column names
+func (r *sqlRepository) query(ctx context.Context, query string, args []any) ([]map[string]any, error) {
+	rows, err := r.exec.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, r.translateError(err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var records []map[string]any
+	for rows.Next() {
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, r.translateError(err)
+		}
+
+		record := make(map[string]any, len(columns))
+		for i, name := range columns {
+			if bytes, ok := values[i].([]byte); ok {
+				values[i] = string(bytes)
+			}
+			record[name] = values[i]
+		}
+		records = append(records, record)
+	}
+	return records, r.translateError(rows.Err())
+}
+
+func (r *sqlRepository) GetByID(ctx context.Context, model *core.Model, id string) (*map[string]any, error) {
+	statement := &sqlStatement{dialect: r.dialect}
+	if err := r.scoped(ctx, model, statement); err != nil {
+		return nil, err
+	}
+	statement.conditions = append(statement.conditions, quote(model.Meta.PrimaryKey())+" = "+statement.bind(id))
+
+	records, err := r.query(ctx, fmt.Sprintf("SELECT %s FROM %s%s LIMIT 1", selectColumns(model),
+		quote(core.Core{}.ExactTableGorm(model.Ref)), statement.where()), statement.args)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errs.NotFound("record not found", nil)
+	}
+
+	convertValues(model, records[0])
+	entity, err := typedRecord(ctx, model, records[0])
+	if err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+func (r *sqlRepository) GetList(ctx context.Context, model *core.Model, page int, pageSize int,
+	filter core.IFilter, order_by string) ([]*map[string]any, int64, error) {
+	if err := checkReadable(ctx, model, filter, order_by); err != nil {
+		return nil, 0, err
+	}
+
+	statement := &sqlStatement{dialect: r.dialect}
+	if err := r.scoped(ctx, model, statement); err != nil {
+		return nil, 0, err
+	}
+	if !filter.IsEmpty() {
+		// The columns of the filter are written into the sql, so only the columns of the model are accepted
+		for _, name := range filter.Columns() {
+			if _, err := column(model, name); err != nil {
+				return nil, 0, err
+			}
+		}
+		filter.MapColumns(func(name string) string {
+			quoted, _ := column(model, name)
+			return quoted
+		})
+		condition, args, err := filter.BuildSQL(r.dialect.Placeholder, statement.args)
+		if err != nil {
+			return nil, 0, errs.BadRequest("", err)
+		}
+		statement.conditions = append(statement.conditions, condition)
+		statement.args = args
+	}
+
+	var orders []string
+	for _, clause := range strings.Split(order_by, ",") {
+		parts := strings.Fields(clause)
+		if len(parts) == 0 {
+			continue
+		}
+		if len(parts) > 2 || (len(parts) == 2 && !slices.Contains([]string{"ASC", "DESC"}, strings.ToUpper(parts[1]))) {
+			return nil, 0, errs.BadRequest(fmt.Sprintf("invalid order_by: %s", clause), nil)
+		}
+		quoted, err := column(model, parts[0])
+		if err != nil {
+			return nil, 0, err
+		}
+		orders = append(orders, strings.TrimSpace(quoted+" "+strings.ToUpper(strings.Join(parts[1:], ""))))
+	}
+
+	table := quote(core.Core{}.ExactTableGorm(model.Ref))
+	counts, err := r.query(ctx, fmt.Sprintf("SELECT COUNT(*) AS total FROM %s%s", table, statement.where()), statement.args)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := strconv.ParseInt(fmt.Sprint(counts[0]["total"]), 10, 64)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s%s", selectColumns(model), table, statement.where())
+	if len(orders) > 0 {
+		query += " ORDER BY " + strings.Join(orders, ", ")
+	}
+	query += fmt.Sprintf(" LIMIT %d OFFSET %d", pageSize, max((page-1)*pageSize, 0))
+	records, err := r.query(ctx, query, statement.args)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var result []*map[string]any
+	for _, record := range records {
+		convertValues(model, record)
+		entity, err := typedRecord(ctx, model, record)
+		if err != nil {
+			return nil, 0, err
+		}
+		result = append(result, &entity)
+	}
+	return result, total, nil
+}
+
+// convertValues converts the values scanned from the driver to the types of the struct fields, as gorm does,
+// e.g. sqlite returns the booleans as integers
+func convertValues(model *core.Model, record map[string]any) {
+	for _, field := range model.Meta.Fields {
+		value := record[field.DBName]
+		if value == nil || kindOf(model, field) != kindBool {
+			continue
+		}
+		if boolean, err := strconv.ParseBool(fmt.Sprint(value)); err == nil {
+			record[field.DBName] = boolean
+		}
+	}
+}
+
+// columnValues returns the input data by the column names, sorted so the sql is stable,
+// the unknown fields are ignored
+func columnValues(model *core.Model, inputData map[string]any) ([]string, []any) {
+	values := make(map[string]any, len(inputData))
+	for key, value := range inputData {
+		if field, ok := model.Meta.Field(key); ok {
+			values[field.DBName] = value
+		}
+	}
+
+	var columns []string
+	var args []any
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		columns = append(columns, name)
+		args = append(args, values[name])
+	}
+	return columns, args
+}
+
+func (r *sqlRepository) Create(ctx context.Context, model *core.Model, inputData *map[string]any) (*map[string]any, error) {
+	if err := typedPayload(ctx, model, inputData); err != nil {
+		return nil, err
+	}
+
+	if err := stampCreate(ctx, model, inputData); err != nil {
+		return nil, err
+	}
+
+	columns, args := columnValues(model, *inputData)
+	statement := &sqlStatement{dialect: r.dialect}
+	placeholders := make([]string, len(args))
+	quoted := make([]string, len(columns))
+	for i := range columns {
+		quoted[i] = quote(columns[i])
+		placeholders[i] = statement.bind(args[i])
+	}
+
+	table := quote(core.Core{}.ExactTableGorm(model.Ref))
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) RETURNING %s", table,
+		strings.Join(quoted, ", "), strings.Join(placeholders, ", "), quote(model.Meta.PrimaryKey()))
+	if len(columns) == 0 {
+		query = fmt.Sprintf("INSERT INTO %s DEFAULT VALUES RETURNING %s", table, quote(model.Meta.PrimaryKey()))
+	}
+	records, err := r.query(ctx, query, statement.args)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errs.Internal("the created record wasn't returned", nil)
+	}
+	return r.GetByID(ctx, model, fmt.Sprint(records[0][model.Meta.PrimaryKey()]))
+}
+
+func (r *sqlRepository) Update(ctx context.Context, model *core.Model, inputData *map[string]any, id string) (*map[string]any, error) {
+	if err := typedPayload(ctx, model, inputData); err != nil {
+		return nil, err
+	}
+
+	stampUpdate(model, inputData)
+
+	columns, args := columnValues(model, *inputData)
+	if len(columns) > 0 {
+		statement := &sqlStatement{dialect: r.dialect}
+		assignments := make([]string, len(columns))
+		for i := range columns {
+			assignments[i] = quote(columns[i]) + " = " + statement.bind(args[i])
+		}
+		if err := r.execByID(ctx, model, id, statement, "UPDATE %s SET "+strings.Join(assignments, ", ")); err != nil {
+			return nil, err
+		}
+	}
+	return r.GetByID(ctx, model, id)
+}
+
+func (r *sqlRepository) Delete(ctx context.Context, model *core.Model, id string) error {
+	statement := &sqlStatement{dialect: r.dialect}
+	if model.Meta.SoftDeletedField != nil {
+		// Soft delete
+		return r.execByID(ctx, model, id, statement,
+			"UPDATE %s SET "+quote(model.Meta.SoftDeletedField.DBName)+" = "+statement.bind(time.Now()))
+	}
+	return r.execByID(ctx, model, id, statement, "DELETE FROM %s")
+}
+
+// execByID runs the statement of format on the visible record of id, the table is formatted into format.
+// It returns a not found error if no record is affected
+func (r *sqlRepository) execByID(ctx context.Context, model *core.Model, id string, statement *sqlStatement, format string) error {
+	if err := r.scoped(ctx, model, statement); err != nil {
+		return err
+	}
+	statement.conditions = append(statement.conditions, quote(model.Meta.PrimaryKey())+" = "+statement.bind(id))
+
+	query := fmt.Sprintf(format, quote(core.Core{}.ExactTableGorm(model.Ref))) + statement.where()
+	result, err := r.exec.ExecContext(ctx, query, statement.args...)
+	if err != nil {
+		return r.translateError(err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return r.translateError(err)
+	}
+	if affected == 0 {
+		return errs.NotFound("record not found", nil)
+	}
+	return nil
+}
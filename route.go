@@ -1,8 +1,13 @@
 package crud_generator
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"path"
+	"strings"
+	"time"
 
 	"github.com/duytacong24895/go-crud-generator/core"
 	"github.com/duytacong24895/go-crud-generator/handler"
@@ -10,44 +15,206 @@ import (
 	"github.com/duytacong24895/go-crud-generator/repositories"
 	"github.com/duytacong24895/go-crud-generator/runtime"
 	"github.com/duytacong24895/go-crud-generator/services"
-	"github.com/go-chi/chi/v5"
 	"gorm.io/gorm"
 )
 
 type ICRUDGenerator interface {
 	Run()
-	RegisterModel(ref any) ICRUDGenerator
+	Handler() http.Handler
+	Routes() []Route
+	RegisterModel(ref any, opts ...ModelOption) IModelRegistration
 	RegisterMiddleware(middleware func(next http.Handler) http.Handler) ICRUDGenerator
 	RegisterDTOForGetDetail(func(w http.ResponseWriter, r *http.Request, ref any) any) ICRUDGenerator
 	RegisterDTOForGetList(func(w http.ResponseWriter, r *http.Request, ref any, total, page, pageSize uint) any) ICRUDGenerator
-	RegisterDTOForError(func(w http.ResponseWriter, r *http.Request, err error, errMsg string) any) ICRUDGenerator
+	RegisterDTOForError(func(w http.ResponseWriter, r *http.Request, err error, errMsg string, status int) any) ICRUDGenerator
+	RegisterHooks(model any, hooks Hooks) ICRUDGenerator
+	RegisterScope(model any, scope core.Scope) ICRUDGenerator
+	RegisterTenantResolver(resolver func(r *http.Request) (any, error)) ICRUDGenerator
+	RegisterAuthorizer(authorizer core.Authorizer, subjectResolver func(r *http.Request) (any, error)) ICRUDGenerator
+	RegisterRoleResolver(resolver func(ctx context.Context) string) ICRUDGenerator
+	RegisterFieldPermission(model any, field string, readRoles, writeRoles []string) ICRUDGenerator
+	RegisterAction(model any, name, method string, handlerFunc handler.ActionFunc) ICRUDGenerator
+	RegisterComputedField(model any, name string, compute func(ctx context.Context, record map[string]any) (any, error)) ICRUDGenerator
+	RegisterComputedExpr(model any, name, expr string, opts ...ComputedOption) ICRUDGenerator
+	RegisterRepository(model any, repository repositories.IRepository) ICRUDGenerator
 }
 
+// Hooks are the callbacks run around the CRUD operations of a model, read core.Hooks for more detail
+type Hooks = core.Hooks
+
+// ActionContext is passed to the handlers of the custom actions, read handler.ActionContext for more detail
+type ActionContext = handler.ActionContext
+
 type crudGenerator struct {
-	router      *chi.Mux
-	handler     *handler.Handler
-	core        *core.Core
-	middlewares []func(next http.Handler) http.Handler
+	router  Router
+	handler *handler.Handler
+	models  *runtime.RegisteredModels
+	// repositories are the repositories of the models, gorm is used for the models without one
+	repositories *repositories.ModelRepositories
+	basePath     string
+	naming       NamingStrategy
+	core         *core.Core
+	middlewares  []func(next http.Handler) http.Handler
+	// queryTimeout is the deadline of the requests of the models without their own timeout
+	queryTimeout time.Duration
+	logger       *slog.Logger
+	// instrumentations observe the requests of the models and the calls of their repositories
+	instrumentations []Instrumentation
 }
 
-func NewCRUDGenerator(router *chi.Mux, db *gorm.DB) ICRUDGenerator {
+// NewCRUDGenerator creates a generator mounted on router by Run, router can be nil if
+// the routes are served with Handler
+func NewCRUDGenerator(router Router, db *gorm.DB, opts ...GeneratorOption) ICRUDGenerator {
 	core := &core.Core{}
-	return &crudGenerator{
-		router: router,
-		core:   core,
+	models := runtime.NewRegisteredModels()
+	modelRepositories := repositories.NewModelRepositories(repositories.NewRepository(db))
+	generator := &crudGenerator{
+		router:       router,
+		core:         core,
+		models:       models,
+		repositories: modelRepositories,
+		basePath:     DefaultBasePath,
+		naming:       StructName,
+		logger:       slog.Default(),
 		handler: &handler.Handler{
-			Service:    services.NewService(repositories.NewRepository(db)),
-			ListModels: runtime.GetListModels().List,
+			Service: services.NewService(modelRepositories),
+			Models:  models,
 		},
 	}
+	for _, opt := range opts {
+		opt(generator)
+	}
+	if len(generator.instrumentations) > 0 {
+		var repository repositories.IRepository = modelRepositories
+		for i := len(generator.instrumentations) - 1; i >= 0; i-- {
+			repository = generator.instrumentations[i].Repository(repository)
+		}
+		generator.handler.Service = services.NewService(repository)
+	}
+	return generator
+}
+
+func (c *crudGenerator) RegisterModel(model any, opts ...ModelOption) IModelRegistration {
+	registered := c.registeredModel(model)
+	for _, opt := range opts {
+		opt(registered)
+	}
+	return &modelRegistration{
+		crudGenerator: c,
+		model:         registered,
+	}
 }
 
-func (c *crudGenerator) RegisterModel(model any) ICRUDGenerator {
-	if c.core.IsPointeOfStruct(model) {
-		runtime.GetListModels().Add(core.NewModel(model))
-	} else {
+// registeredModel returns the registered model of ref, the model is registered if it's not yet
+func (c *crudGenerator) registeredModel(ref any) *core.Model {
+	if !c.core.IsPointeOfStruct(ref) {
 		panic("Model must be a pointer to a struct")
 	}
+
+	model, ok := c.models.Get(c.core.ExactModelName(ref))
+	if !ok {
+		model = core.NewModel(ref)
+		c.models.Add(model)
+	}
+	return model
+}
+
+// RegisterHooks adds hooks to the model, they run after the hooks which are already registered,
+// e.g. the ones added by Register
+func (c *crudGenerator) RegisterHooks(model any, hooks Hooks) ICRUDGenerator {
+	registered := c.registeredModel(model)
+	registered.Hooks = registered.Hooks.Then(hooks)
+	return c
+}
+
+// RegisterScope adds a scope applied to the reads, the updates and the deletes of the model
+func (c *crudGenerator) RegisterScope(model any, scope core.Scope) ICRUDGenerator {
+	registered := c.registeredModel(model)
+	registered.Scopes = append(registered.Scopes, scope)
+	return c
+}
+
+// RegisterTenantResolver sets the resolver of the tenant of the requests,
+// it's required by the models with a tenant field
+func (c *crudGenerator) RegisterTenantResolver(resolver func(r *http.Request) (any, error)) ICRUDGenerator {
+	c.handler.TenantResolver = resolver
+	return c
+}
+
+// RegisterAuthorizer sets the authorizer consulted before every operation,
+// the subject passed to the authorizer is resolved from the request by subjectResolver
+func (c *crudGenerator) RegisterAuthorizer(authorizer core.Authorizer,
+	subjectResolver func(r *http.Request) (any, error)) ICRUDGenerator {
+	c.handler.Authorizer = authorizer
+	c.handler.SubjectResolver = subjectResolver
+	return c
+}
+
+// RegisterRoleResolver sets the resolver of the role of the requests,
+// the field permissions are evaluated against the role
+func (c *crudGenerator) RegisterRoleResolver(resolver func(ctx context.Context) string) ICRUDGenerator {
+	c.handler.RoleResolver = resolver
+	return c
+}
+
+// RegisterFieldPermission restricts the roles which can read or write a field of the model,
+// field can be the name of the struct field or the column, an empty list of roles allows every role
+func (c *crudGenerator) RegisterFieldPermission(model any, field string, readRoles, writeRoles []string) ICRUDGenerator {
+	registered := c.registeredModel(model)
+	modelField, ok := registered.Meta.Field(field)
+	if !ok {
+		panic(fmt.Sprintf("Field %s not found in model %s", field, registered.Name))
+	}
+
+	registered.Meta.FieldPermissions = append(registered.Meta.FieldPermissions, &core.FieldPermission{
+		Field:      modelField,
+		ReadRoles:  readRoles,
+		WriteRoles: writeRoles,
+	})
+	return c
+}
+
+// RegisterAction adds a custom action to the model, it's mounted on
+// {basePath}/{resource}/_actions/{name} and {basePath}/{resource}/{id}/_actions/{name}
+func (c *crudGenerator) RegisterAction(model any, name, method string, handlerFunc handler.ActionFunc) ICRUDGenerator {
+	c.handler.AddAction(c.registeredModel(model), &handler.CustomAction{
+		Name:    name,
+		Method:  method,
+		Handler: handlerFunc,
+	})
+	return c
+}
+
+// RegisterComputedField adds a field computed from each record returned to the client
+func (c *crudGenerator) RegisterComputedField(model any, name string,
+	compute func(ctx context.Context, record map[string]any) (any, error)) ICRUDGenerator {
+	registered := c.registeredModel(model)
+	registered.ComputedFields = append(registered.ComputedFields, &core.ComputedField{
+		Name:    name,
+		Compute: compute,
+	})
+	return c
+}
+
+// RegisterComputedExpr adds a field computed by a sql expression, it can be filtered and sorted like a column
+// Example: RegisterComputedExpr(&User{}, "full_name", "first_name || ' ' || last_name")
+func (c *crudGenerator) RegisterComputedExpr(model any, name, expr string, opts ...ComputedOption) ICRUDGenerator {
+	registered := c.registeredModel(model)
+	field := &core.ComputedField{
+		Name: name,
+		Expr: expr,
+	}
+	for _, opt := range opts {
+		opt(field)
+	}
+	registered.ComputedFields = append(registered.ComputedFields, field)
+	return c
+}
+
+// RegisterRepository sets the repository of the model instead of the gorm one, e.g. a view or an external store
+// The default repository can be decorated with repositories.NewRepository(db)
+func (c *crudGenerator) RegisterRepository(model any, repository repositories.IRepository) ICRUDGenerator {
+	c.repositories.Set(c.registeredModel(model).Name, repository)
 	return c
 }
 
@@ -66,29 +233,82 @@ func (c *crudGenerator) RegisterDTOForGetList(returndto func(w http.ResponseWrit
 	return c
 }
 
-func (c *crudGenerator) RegisterDTOForError(returndto func(w http.ResponseWriter, r *http.Request, err error, errMsg string) any) ICRUDGenerator {
+func (c *crudGenerator) RegisterDTOForError(returndto func(w http.ResponseWriter, r *http.Request, err error, errMsg string, status int) any) ICRUDGenerator {
 	c.handler.DTOError = returndto
 	return c
 }
 
-func (c *crudGenerator) Run() {
-	c.router.Route("/crud", func(r chi.Router) {
-		r = r.With(middlewares.VerifyModel)
-		for _, middleware := range c.middlewares {
-			r = r.With(middleware)
+// Route is a route of the generator, the params of Pattern are written as {name},
+// Method is empty for the routes which accept every method
+type Route struct {
+	Method  string
+	Pattern string
+	Handler http.Handler
+}
+
+// Routes returns the routes of the generator under the base path, the params of the urls are read
+// with Request.PathValue, so the adapters of the routers must set them with Request.SetPathValue
+func (c *crudGenerator) Routes() []Route {
+	for _, model := range c.models.List() {
+		resource := model.Resource
+		if resource == "" {
+			resource = c.naming(model)
 		}
-		r.Get("/{modelName}", c.handler.GetList)
-		r.Get("/{modelName}/{id}", c.handler.GetListById)
-		r.Post("/{modelName}", c.handler.Create)
-		r.Put("/{modelName}/{id}", c.handler.Update)
-		r.Delete("/{modelName}/{id}", c.handler.Delete)
-	})
+		if err := c.models.SetResource(model, resource); err != nil {
+			panic(err.Error())
+		}
+	}
+
+	prefix := strings.TrimSuffix(c.basePath, "/")
+	return []Route{
+		{Method: http.MethodGet, Pattern: prefix + "/{modelName}", Handler: c.chain(c.handler.GetList)},
+		{Method: http.MethodGet, Pattern: prefix + "/{modelName}/{id}", Handler: c.chain(c.handler.GetListById)},
+		{Method: http.MethodPost, Pattern: prefix + "/{modelName}", Handler: c.chain(c.handler.Create)},
+		{Method: http.MethodPut, Pattern: prefix + "/{modelName}/{id}", Handler: c.chain(c.handler.Update)},
+		{Method: http.MethodDelete, Pattern: prefix + "/{modelName}/{id}", Handler: c.chain(c.handler.Delete)},
+		{Pattern: prefix + "/{modelName}/_actions/{action}", Handler: c.chain(c.handler.RunAction)},
+		{Pattern: prefix + "/{modelName}/{id}/_actions/{action}", Handler: c.chain(c.handler.RunAction)},
+	}
+}
+
+// chain wraps the handler of an operation with the middlewares of the generator and of the models,
+// the model of the request is resolved first
+func (c *crudGenerator) chain(handlerFunc http.HandlerFunc) http.Handler {
+	var handler http.Handler = middlewares.ModelMiddlewares(handlerFunc)
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		handler = c.middlewares[i](handler)
+	}
+	handler = middlewares.Logger(c.logger)(middlewares.Timeout(c.queryTimeout)(handler))
+	for i := len(c.instrumentations) - 1; i >= 0; i-- {
+		handler = c.instrumentations[i].Middleware(handler)
+	}
+	return middlewares.VerifyModel(c.handler)(handler)
+}
+
+// Handler returns the routes of the generator as a plain http.Handler, the params of the urls are
+// matched under the base path, so it can be mounted on any router or served by itself.
+// The other methods of the urls of the models are responded as not allowed by the handler of the generator
+func (c *crudGenerator) Handler() http.Handler {
+	mux := http.NewServeMux()
+	for _, route := range c.Routes() {
+		mux.Handle(strings.TrimSpace(route.Method+" "+route.Pattern), route.Handler)
+	}
+
+	prefix := strings.TrimSuffix(c.basePath, "/")
+	notAllowed := c.chain(c.handler.MethodNotAllowed)
+	mux.Handle(prefix+"/{modelName}", notAllowed)
+	mux.Handle(prefix+"/{modelName}/{id}", notAllowed)
+	return mux
+}
+
+// Run mounts the routes of the generator on the base path of the router
+func (c *crudGenerator) Run() {
+	c.router.Mount(c.basePath, c.Handler())
 
-	listModelNames := make([]string, len(runtime.GetListModels().List))
-	for i, model := range runtime.GetListModels().List {
-		listModelNames[i] = model.Name
+	listModels := c.models.List()
+	listModelNames := make([]string, len(listModels))
+	for i, model := range listModels {
+		listModelNames[i] = path.Join(c.basePath, model.Resource)
 	}
-	fmt.Println("CRUD generator initialized")
-	fmt.Println("List models:", listModelNames)
-	fmt.Println("CRUD generator routes registered")
+	c.logger.Info("crud generator routes registered", "base_path", c.basePath, "models", listModelNames)
 }
@@ -0,0 +1,26 @@
+package crud_generator
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Router mounts the routes of the generator on a base path, *chi.Mux is a Router,
+// ServeMux adapts *http.ServeMux
+type Router interface {
+	Mount(pattern string, handler http.Handler)
+}
+
+type serveMux struct {
+	mux *http.ServeMux
+}
+
+// ServeMux adapts mux to a Router, the routes are registered under the base path with the patterns of Go 1.22
+// Example: NewCRUDGenerator(crud_generator.ServeMux(http.NewServeMux()), db)
+func ServeMux(mux *http.ServeMux) Router {
+	return &serveMux{mux: mux}
+}
+
+func (s *serveMux) Mount(pattern string, handler http.Handler) {
+	s.mux.Handle(strings.TrimSuffix(pattern, "/")+"/", handler)
+}
@@ -1,36 +1,79 @@
 package runtime
 
 import (
+	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/duytacong24895/go-crud-generator/core"
 )
 
-var once sync.Once
-
+// RegisteredModels is the registry of the models of a generator,
+// the models are looked up by their name
 type RegisteredModels struct {
-	List []*core.Model
+	mu     sync.RWMutex
+	models map[string]*core.Model
+	// names keeps the order of registration
+	names []string
+	// resources indexes the models by their lower case resource
+	resources map[string]*core.Model
+}
+
+func NewRegisteredModels() *RegisteredModels {
+	return &RegisteredModels{
+		models:    make(map[string]*core.Model),
+		resources: make(map[string]*core.Model),
+	}
 }
 
 func (r *RegisteredModels) Add(model *core.Model) {
-	// Check if the model already exists in the list
-	for _, existingModel := range r.List {
-		if existingModel.Name == model.Name {
-			// If it exists, we do not add it again
-			return
-		}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// If the model already exists, we do not add it again
+	if _, ok := r.models[model.Name]; ok {
+		return
 	}
-	r.List = append(r.List, model)
+	r.models[model.Name] = model
+	r.names = append(r.names, model.Name)
 }
 
-var registeredModels *RegisteredModels
+func (r *RegisteredModels) Get(name string) (*core.Model, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	model, ok := r.models[name]
+	return model, ok
+}
 
-func GetListModels() *RegisteredModels {
-	once.Do(func() {
-		// Register your custom values here
-		registeredModels = &RegisteredModels{
-			List: make([]*core.Model, 0),
-		}
-	})
-	return registeredModels
+// SetResource sets the resource of the model, the resources are case insensitive
+// so they can't be shared by two models
+func (r *RegisteredModels) SetResource(model *core.Model, resource string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := strings.ToLower(resource)
+	if existing, ok := r.resources[key]; ok && existing != model {
+		return fmt.Errorf("resource %s of model %s is already used by model %s", resource, model.Name, existing.Name)
+	}
+	delete(r.resources, strings.ToLower(model.Resource))
+	model.Resource = resource
+	r.resources[key] = model
+	return nil
+}
+
+// Resolve returns the model of the resource, regardless of the case
+func (r *RegisteredModels) Resolve(resource string) (*core.Model, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	model, ok := r.resources[strings.ToLower(resource)]
+	return model, ok
+}
+
+// List returns the models in the order of registration
+func (r *RegisteredModels) List() []*core.Model {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]*core.Model, len(r.names))
+	for i, name := range r.names {
+		list[i] = r.models[name]
+	}
+	return list
 }
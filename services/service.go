@@ -1,21 +1,28 @@
 package services
 
 import (
+	"context"
 	"fmt"
+	"slices"
 
 	"github.com/duytacong24895/go-crud-generator/core"
 	"github.com/duytacong24895/go-crud-generator/dtos"
+	"github.com/duytacong24895/go-crud-generator/errs"
 	"github.com/duytacong24895/go-crud-generator/repositories"
-
-	"gorm.io/gorm"
 )
 
 type IService interface {
-	Create(model *core.Model, inputData *map[string]any) (any, error)
-	GetByID(model *core.Model, id string) (any, error)
-	GetList(model *core.Model, inputData *dtos.GetListQueryParams) ([]*map[string]any, int64, error)
-	Update(model *core.Model, inputData *map[string]any, id string) (*map[string]any, error)
-	Delete(model *core.Model, id string) error
+	Create(ctx context.Context, model *core.Model, inputData *map[string]any) (*map[string]any, error)
+	GetByID(ctx context.Context, model *core.Model, id string) (*map[string]any, error)
+	// Find returns the record of id as the repository stores it, without the computed fields,
+	// the masking of the fields and the narrowing to the requested fields
+	Find(ctx context.Context, model *core.Model, id string) (*map[string]any, error)
+	GetList(ctx context.Context, model *core.Model, inputData *dtos.GetListQueryParams) ([]*map[string]any, int64, error)
+	Update(ctx context.Context, model *core.Model, inputData *map[string]any, id string) (*map[string]any, error)
+	Delete(ctx context.Context, model *core.Model, id string) error
+	// Transaction runs fn with a service bound to a transaction of the repository of model,
+	// the transaction is committed if fn returns nil
+	Transaction(ctx context.Context, model *core.Model, fn func(tx IService) error) error
 }
 type service struct {
 	repository repositories.IRepository
@@ -27,47 +34,194 @@ func NewService(repository repositories.IRepository) IService {
 	}
 }
 
-func (s *service) Create(model *core.Model, inputData *map[string]any) (any, error) {
-	entity, err := s.repository.Create(model, inputData)
+func (s *service) Create(ctx context.Context, model *core.Model, inputData *map[string]any) (*map[string]any, error) {
+	if err := checkWritable(ctx, model, inputData); err != nil {
+		return nil, err
+	}
+
+	var entity *map[string]any
+	err := s.repository.Transaction(ctx, model, func(repo repositories.IRepository) error {
+		if hook := model.Hooks.BeforeCreate; hook != nil {
+			if err := hook(ctx, model, inputData); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		entity, err = repo.Create(ctx, model, inputData)
+		if err != nil {
+			return err
+		}
+
+		if hook := model.Hooks.AfterCreate; hook != nil {
+			return hook(ctx, model, entity)
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+
+	if err := s.present(ctx, model, entity); err != nil {
+		return nil, err
+	}
 	return entity, nil
 }
 
-func (s *service) GetByID(model *core.Model, id string) (any, error) {
-	entity, err := s.repository.GetByID(model, id)
+func (s *service) GetByID(ctx context.Context, model *core.Model, id string) (*map[string]any, error) {
+	entity, err := s.repository.GetByID(ctx, model, id)
 	if err != nil {
 		return nil, err
 	}
+
+	if err := s.present(ctx, model, entity); err != nil {
+		return nil, err
+	}
 	return entity, nil
 }
 
-func (s *service) GetList(model *core.Model, inputData *dtos.GetListQueryParams) ([]*map[string]any, int64, error) {
-	entities, total, err := s.repository.GetList(model, inputData.Page, inputData.PageSize,
+func (s *service) Find(ctx context.Context, model *core.Model, id string) (*map[string]any, error) {
+	return s.repository.GetByID(ctx, model, id)
+}
+
+func (s *service) GetList(ctx context.Context, model *core.Model, inputData *dtos.GetListQueryParams) ([]*map[string]any, int64, error) {
+	entities, total, err := s.repository.GetList(ctx, model, inputData.Page, inputData.PageSize,
 		inputData.Filter, inputData.OrderBy)
 	if err != nil {
 		return nil, 0, err
 	}
+
+	for _, entity := range entities {
+		if err := s.present(ctx, model, entity); err != nil {
+			return nil, 0, err
+		}
+	}
 	return entities, total, nil
 }
 
-func (s *service) Update(model *core.Model, inputData *map[string]any, id string) (*map[string]any, error) {
-	_, err := s.repository.GetByID(model, id)
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("record not found")
-		}
+func (s *service) Update(ctx context.Context, model *core.Model, inputData *map[string]any, id string) (*map[string]any, error) {
+	if err := checkWritable(ctx, model, inputData); err != nil {
 		return nil, err
 	}
 
-	entities, err := s.repository.Update(model, inputData, id)
+	var entity *map[string]any
+	err := s.repository.Transaction(ctx, model, func(repo repositories.IRepository) error {
+		current, err := repo.GetByID(ctx, model, id)
+		if err != nil {
+			return err
+		}
+
+		if hook := model.Hooks.BeforeUpdate; hook != nil {
+			if err := hook(ctx, model, current, inputData); err != nil {
+				return err
+			}
+		}
+
+		entity, err = repo.Update(ctx, model, inputData, id)
+		if err != nil {
+			return err
+		}
+
+		if hook := model.Hooks.AfterUpdate; hook != nil {
+			return hook(ctx, model, entity)
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	return entities, nil
+
+	if err := s.present(ctx, model, entity); err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+func (s *service) Delete(ctx context.Context, model *core.Model, id string) error {
+	if model.Hooks.BeforeDelete == nil && model.Hooks.AfterDelete == nil {
+		return s.repository.Delete(ctx, model, id)
+	}
+
+	return s.repository.Transaction(ctx, model, func(repo repositories.IRepository) error {
+		current, err := repo.GetByID(ctx, model, id)
+		if err != nil {
+			return err
+		}
+
+		if hook := model.Hooks.BeforeDelete; hook != nil {
+			if err := hook(ctx, model, current); err != nil {
+				return err
+			}
+		}
+
+		if err := repo.Delete(ctx, model, id); err != nil {
+			return err
+		}
+
+		if hook := model.Hooks.AfterDelete; hook != nil {
+			return hook(ctx, model, current)
+		}
+		return nil
+	})
+}
+
+func (s *service) Transaction(ctx context.Context, model *core.Model, fn func(tx IService) error) error {
+	return s.repository.Transaction(ctx, model, func(repo repositories.IRepository) error {
+		return fn(&service{repository: repo})
+	})
+}
+
+// present prepares the record returned to the client, the fields which the role can't read are removed,
+// the computed fields are added, the AfterRead hook is run, then the record is narrowed to the fields
+// requested by the client
+func (s *service) present(ctx context.Context, model *core.Model, entity *map[string]any) error {
+	for _, field := range model.Meta.UnreadableFields(core.RoleFromContext(ctx)) {
+		delete(*entity, model.RecordKey(field))
+	}
+
+	fields := core.FieldsFromContext(ctx)
+	for _, field := range model.ComputedFields {
+		if field.Compute == nil || (len(fields) > 0 && !slices.Contains(fields, field.Name)) {
+			continue
+		}
+		value, err := field.Compute(ctx, *entity)
+		if err != nil {
+			return err
+		}
+		(*entity)[field.Name] = value
+	}
+
+	if model.Hooks.AfterRead != nil {
+		if err := model.Hooks.AfterRead(ctx, model, entity); err != nil {
+			return err
+		}
+	}
+
+	if len(fields) > 0 {
+		for key := range *entity {
+			if !slices.Contains(fields, key) {
+				delete(*entity, key)
+			}
+		}
+	}
+	return nil
 }
 
-func (s *service) Delete(model *core.Model, id string) error {
-	return s.repository.Delete(model, id)
+// checkWritable rejects the input data which contains the fields that the role of the request can't write,
+// the keys of the input data are matched to the fields by MetaModel.Field
+func checkWritable(ctx context.Context, model *core.Model, inputData *map[string]any) error {
+	var err *errs.Error
+	for _, field := range model.Meta.UnwritableFields(core.RoleFromContext(ctx)) {
+		for _, key := range model.Meta.Keys(*inputData, field) {
+			if err == nil {
+				err = errs.Forbidden("you don't have permission to write some fields", nil)
+			}
+			err.WithField(key, fmt.Sprintf("you don't have permission to write %s", key))
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+	return nil
 }
@@ -6,19 +6,115 @@ package main
 // will clean the data after each testcase
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
+	"github.com/duytacong24895/go-crud-generator/repositories"
 	"github.com/duytacong24895/go-crud-generator/tests/pkg/database"
 	"github.com/duytacong24895/go-crud-generator/tests/testcases"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func main() {
 	fmt.Println("Start testing")
 	db := database.InitDB()
+	sqlDB := database.InitSQLDB()
 
 	statistics := &Statistics{}
 
 	statistics.On(testcases.NewTestCaseCreateUser(db, "http://localhost:8080/crud").RunTest())
 	statistics.On(testcases.NewTestCaseUpdateUser(db, "http://localhost:8080/crud").RunTest())
+
+	// The shared cases are run against every server
+	exporter := tracetest.NewInMemoryExporter()
+	tracingServer := testcases.NewTracingServer(db, exporter, 8084)
+	memoryServer := testcases.NewMemoryServer(8089)
+	ginServer := testcases.NewGinServer(db, 8087)
+	boltServer := testcases.NewBoltServer(filepath.Join(os.TempDir(), "crud_generator_test.db"), 8083)
+	sqlServers := []*testcases.Server{
+		testcases.NewSQLServer(db, sqlDB, repositories.DialectSQLite, 8082),
+		testcases.NewSQLServer(db, sqlDB, repositories.DialectPostgres, 8086),
+	}
+	gormServer := testcases.NewGormServer(db, 8085)
+	serveMuxServer := testcases.NewServeMuxServer(db, 8097)
+	servers := []*testcases.Server{
+		gormServer,
+		serveMuxServer,
+		testcases.NewEchoServer(db, 8081),
+		ginServer,
+		memoryServer,
+		testcases.NewModelRepositoryServer(db, 8090),
+		tracingServer,
+		boltServer,
+	}
+	for _, server := range append(servers, sqlServers...) {
+		for _, testCase := range testcases.NewSuite(server) {
+			statistics.On(testCase.RunTest())
+		}
+		for _, testCase := range testcases.NewNullSuite(server) {
+			statistics.On(testCase.RunTest())
+		}
+		for _, testCase := range testcases.NewTenantSuite(server) {
+			statistics.On(testCase.RunTest())
+		}
+	}
+
+	// Then the cases of each server on its own
+	for _, server := range []*testcases.Server{gormServer, serveMuxServer} {
+		for _, testCase := range testcases.NewMethodSuite(server) {
+			statistics.On(testCase.RunTest())
+		}
+	}
+	for _, testCase := range testcases.NewReadOnlySuite(testcases.NewReadOnlyServer(db, 8093)) {
+		statistics.On(testCase.RunTest())
+	}
+	for _, testCase := range testcases.NewRegistrationSuite(testcases.NewRegistrationServer(db, 8094)) {
+		statistics.On(testCase.RunTest())
+	}
+	for _, testCase := range testcases.NewTwoGeneratorsSuite(testcases.NewTwoGeneratorsServer(db, 8095)) {
+		statistics.On(testCase.RunTest())
+	}
+	for _, testCase := range testcases.NewNamingSuite(testcases.NewNamingServer(db, 8096)) {
+		statistics.On(testCase.RunTest())
+	}
+	recorder := &testcases.LogRecorder{}
+	for _, testCase := range testcases.NewTimeoutSuite(testcases.NewTimeoutServer(db, recorder, 8098), recorder) {
+		statistics.On(testCase.RunTest())
+	}
+	for _, server := range []*testcases.Server{
+		testcases.NewComputedServer("computed", db, nil, 8099),
+		testcases.NewComputedServer("computed database/sql", db, repositories.NewSQLRepository(sqlDB, repositories.DialectSQLite), 8100),
+	} {
+		for _, testCase := range testcases.NewComputedSuite(server) {
+			statistics.On(testCase.RunTest())
+		}
+	}
+	for _, testCase := range testcases.NewScopeSuite(testcases.NewScopeServer(db, 8091)) {
+		statistics.On(testCase.RunTest())
+	}
+	for _, testCase := range testcases.NewAuthorizerSuite(testcases.NewAuthorizerServer(db, 8092)) {
+		statistics.On(testCase.RunTest())
+	}
+	for _, server := range sqlServers {
+		for _, testCase := range testcases.NewSQLSuite(server) {
+			statistics.On(testCase.RunTest())
+		}
+	}
+	for _, testCase := range testcases.NewPlaceholderSuite() {
+		statistics.On(testCase.RunTest())
+	}
+	for _, testCase := range testcases.NewGinSuite(ginServer) {
+		statistics.On(testCase.RunTest())
+	}
+	for _, testCase := range testcases.NewTypedSuite(testcases.NewTypedServer(db, 8088)) {
+		statistics.On(testCase.RunTest())
+	}
+	for _, testCase := range testcases.NewBoltSuite(boltServer) {
+		statistics.On(testCase.RunTest())
+	}
+	for _, testCase := range testcases.NewTracingSuite(tracingServer, exporter) {
+		statistics.On(testCase.RunTest())
+	}
 	// statistics.On(testcases.NewTestCaseDetailUser(db, "http://localhost:8080/crud").RunTest())
 	// statistics.On(testcases.NewTestCaseDeleteUser(db, "http://localhost:8080/crud").RunTest())
 	// statistics.On(testcases.NewTestCaseGetListUserWithFilter(db, "http://localhost:8080/crud").RunTest())
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"database/sql"
 	"sync"
 
 	"gorm.io/driver/sqlite" // Sqlite driver based on CGO
@@ -11,6 +12,9 @@ import (
 var db *gorm.DB
 var once sync.Once
 
+var sqlDB *sql.DB
+var sqlOnce sync.Once
+
 func InitDB() *gorm.DB {
 	once.Do(func() {
 		var err error
@@ -21,3 +25,15 @@ func InitDB() *gorm.DB {
 	})
 	return db
 }
+
+// InitSQLDB returns a database/sql handle of the same database, the foreign keys are enforced by its connections
+func InitSQLDB() *sql.DB {
+	sqlOnce.Do(func() {
+		var err error
+		sqlDB, err = sql.Open("sqlite3", "gorm.db?_foreign_keys=on")
+		if err != nil {
+			panic(err)
+		}
+	})
+	return sqlDB
+}
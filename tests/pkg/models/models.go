@@ -15,12 +15,73 @@ type Employee struct {
 	Name   string    `gorm:"column:name" json:"name"`
 	Email  string    `gorm:"column:email" json:"email"`
 	Dob    time.Time `gorm:"column:dob" json:"dob"`
-	Age    int64     `gorm:"column:age" json:"age"`
+	Age    int64     `gorm:"column:age" json:"age" crud_generator:"index"`
 	Phone  string    `gorm:"column:phone" json:"phone"`
 	Mature bool      `gorm:"column:mature" json:"mature"`
+	// Nickname is null when it's not sent
+	Nickname *string `gorm:"column:nickname" json:"nickname"`
 }
 
 // TableName Employee's table name
 func (*Employee) TableName() string {
 	return TableNameEmployee
 }
+
+const TableNameContract = "contract"
+
+// Contract is a contract of an employee, its foreign key to the employees can be violated,
+// and it's soft deleted by every repository as its deleted time is tagged
+type Contract struct {
+	ID         int64      `gorm:"column:id;primaryKey" json:"id"`
+	EmployeeID int64      `gorm:"column:employee_id" json:"employee_id"`
+	Employee   Employee   `gorm:"foreignKey:EmployeeID" json:"-"`
+	Title      string     `gorm:"column:title" json:"title"`
+	DeletedAt  *time.Time `gorm:"column:deleted_at" json:"deleted_at" crud_generator:"soft_delete_field"`
+}
+
+// TableName Contract's table name
+func (*Contract) TableName() string {
+	return TableNameContract
+}
+
+const TableNameAccount = "account"
+
+// Account is an account of an employee, the json names of its fields aren't their columns,
+// its salary can only be read by the role hr
+type Account struct {
+	ID     int64  `gorm:"column:id;primaryKey" json:"id"`
+	Login  string `gorm:"column:login_name" json:"login"`
+	Secret string `gorm:"column:secret_code" json:"secret"`
+	Salary int64  `gorm:"column:salary" json:"salary" crud_generator:"read_roles:hr"`
+}
+
+// TableName Account's table name
+func (*Account) TableName() string {
+	return TableNameAccount
+}
+
+const TableNameInvoice = "invoice"
+
+// Invoice is an invoice of a tenant, the records are filtered and stamped by the tenant of the requests
+type Invoice struct {
+	ID       int64  `gorm:"column:id;primaryKey" json:"id"`
+	Title    string `gorm:"column:title" json:"title"`
+	TenantID string `gorm:"column:tenant_id" json:"tenant_id" crud_generator:"tenant_field"`
+}
+
+// TableName Invoice's table name
+func (*Invoice) TableName() string {
+	return TableNameInvoice
+}
+
+// JobTitle is a title of the employees, its table is named by gorm, job_titles
+type JobTitle struct {
+	ID   int64  `gorm:"column:id;primaryKey" json:"id"`
+	Name string `gorm:"column:name" json:"name"`
+}
+
+// Badge is a badge of the employees, its id has no json tag so its records are keyed by ID
+type Badge struct {
+	ID    int64  `gorm:"column:id;primaryKey"`
+	Label string `gorm:"column:label" json:"label"`
+}
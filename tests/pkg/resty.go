@@ -1,6 +1,7 @@
 package pkg
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -133,6 +134,37 @@ func DeleteRequest(client *resty.Client, url string) (serverAlive bool, err erro
 	return true, nil
 }
 
+// Send sends a request of any method and returns the response whatever its status,
+// so the headers of the responses can be checked as well
+func Send(client *resty.Client, method, url string, body interface{}) (*resty.Response, error) {
+	req := client.R()
+	if body != nil {
+		req.SetBody(body)
+	}
+
+	resp, err := req.Execute(method, url)
+	if err != nil {
+		return nil, fmt.Errorf("%s request failed: %w", method, err)
+	}
+	return resp, nil
+}
+
+// Request sends a request of any method and unmarshals the response into result whatever its status,
+// so the error responses can be checked as well. The status code is returned
+func Request(client *resty.Client, method, url string, body interface{}, result interface{}) (status int, err error) {
+	resp, err := Send(client, method, url, body)
+	if err != nil {
+		return 0, err
+	}
+
+	if result != nil && len(resp.Body()) > 0 {
+		if err := json.Unmarshal(resp.Body(), result); err != nil {
+			return resp.StatusCode(), fmt.Errorf("%s request returned an invalid body: %w", method, err)
+		}
+	}
+	return resp.StatusCode(), nil
+}
+
 // Advanced function with headers and auth
 func RequestWithAuth(client *resty.Client, method, url, token string, body interface{}, result interface{}) (serverAlive bool, err error) {
 	req := client.R().
@@ -1,5 +1,10 @@
 package pkg
 
+import (
+	"fmt"
+	"reflect"
+)
+
 type ITestCase interface {
 	Preparing() error
 	Cleaning() error
@@ -11,50 +16,91 @@ type ITestCase interface {
 	RunTest() (bool, error)
 }
 
-// type NewTestCaseDTO struct {
-// 	Name        string
-// 	Preparing   func()
-// 	Cleaning    func()
-// 	Do          func() error
-// 	CheckResult func() (bool, error)
-// }
-
-// func NewTestCase(input *NewTestCaseDTO) ITestCase {
-// 	return &testCase{
-// 		name:        input.Name,
-// 		preparing:   input.Preparing,
-// 		cleaning:    input.Cleaning,
-// 		do:          input.Do,
-// 		checkResult: input.CheckResult,
-// 	}
-// }
-
-// type testCase struct {
-// 	name        string
-// 	preparing   func()
-// 	cleaning    func()
-// 	do          func() error
-// 	checkResult func() (bool, error)
-// }
-
-// func (t *testCase) Preparing() {
-// 	println("Preparing " + t.name)
-// 	t.preparing()
-// }
-
-// func (t *testCase) Cleaning() {
-// 	println("Cleaning " + t.name)
-// 	t.cleaning()
-// }
-
-// func (t *testCase) Do() error {
-// 	return t.do()
-// }
-
-// func (t *testCase) CheckResult() (bool, error) {
-// 	return t.checkResult()
-// }
-
-// func (t *testCase) Name() string {
-// 	return t.name
-// }
+// NewTestCaseDTO is the steps of a test case, Cleaning can be nil
+type NewTestCaseDTO struct {
+	Name        string
+	Preparing   func() error
+	Cleaning    func() error
+	Do          func() error
+	GetExpected func() (any, error)
+	GetActual   func() (any, error)
+}
+
+// NewTestCase returns a test case running the steps of input, it passes when the actual result equals the expected one
+func NewTestCase(input *NewTestCaseDTO) ITestCase {
+	return &testCase{input: input}
+}
+
+type testCase struct {
+	input *NewTestCaseDTO
+}
+
+func (t *testCase) Preparing() error {
+	return t.input.Preparing()
+}
+
+func (t *testCase) Cleaning() error {
+	if t.input.Cleaning == nil {
+		return nil
+	}
+	return t.input.Cleaning()
+}
+
+func (t *testCase) Name() string {
+	return t.input.Name
+}
+
+func (t *testCase) Do() error {
+	return t.input.Do()
+}
+
+func (t *testCase) GetExpected() (any, error) {
+	return t.input.GetExpected()
+}
+
+func (t *testCase) GetActual() (any, error) {
+	return t.input.GetActual()
+}
+
+func (t *testCase) CheckResult() (bool, error) {
+	expect, err := t.GetExpected()
+	if err != nil {
+		return false, err
+	}
+	actual, err := t.GetActual()
+	if err != nil {
+		return false, err
+	}
+	if !reflect.DeepEqual(expect, actual) {
+		fmt.Printf("[testcase][%s] expected %v, got %v\n", t.Name(), expect, actual)
+		return false, nil
+	}
+	return true, nil
+}
+
+func (t *testCase) RunTest() (bool, error) {
+	fmt.Printf("================[testcase][%s] is running...================\n", t.Name())
+	defer t.Cleaning()
+
+	if err := t.Preparing(); err != nil {
+		fmt.Printf("[testcase][%s] got a Error: %v\n", t.Name(), err)
+		return false, err
+	}
+	if err := t.Do(); err != nil {
+		fmt.Printf("[testcase][%s] got a Error: %v\n", t.Name(), err)
+		return false, err
+	}
+	result, err := t.CheckResult()
+	if err != nil {
+		fmt.Printf("[testcase][%s] got a Error: %v\n", t.Name(), err)
+		return false, err
+	}
+
+	if result {
+		fmt.Printf("[testcase][%s] was passed\n", t.Name())
+		return true, nil
+	} else {
+		fmt.Printf("[testcase][%s] was failed\n", t.Name())
+		return false, nil
+	}
+}
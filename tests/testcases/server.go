@@ -0,0 +1,535 @@
+package testcases
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	crud_generator "github.com/duytacong24895/go-crud-generator"
+	"github.com/duytacong24895/go-crud-generator/core"
+	crudecho "github.com/duytacong24895/go-crud-generator/echo"
+	"github.com/duytacong24895/go-crud-generator/errs"
+	crudgin "github.com/duytacong24895/go-crud-generator/gin"
+	crudotel "github.com/duytacong24895/go-crud-generator/otel"
+	"github.com/duytacong24895/go-crud-generator/repositories"
+	"github.com/duytacong24895/go-crud-generator/tests/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/chi/v5"
+	"github.com/labstack/echo/v4"
+	bolt "go.etcd.io/bbolt"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"gorm.io/gorm"
+)
+
+// rollbackName is the name of the employees failing the AfterCreate hook, their creation must be rolled back
+const rollbackName = "rollback"
+
+// Server serves a generator on a port, the shared cases are run against each server,
+// so every router and repository is checked by the same requests
+type Server struct {
+	name     string
+	port     int
+	setup    func() (http.Handler, error)
+	handler  atomic.Pointer[http.Handler]
+	listener net.Listener
+}
+
+// NewServer returns the server of name listening on port, setup empties the store of the server
+// and returns the handler of a new generator, it's run before each case
+func NewServer(name string, port int, setup func() (http.Handler, error)) *Server {
+	return &Server{
+		name:  name,
+		port:  port,
+		setup: setup,
+	}
+}
+
+func (s *Server) Name() string {
+	return s.name
+}
+
+func (s *Server) Url() string {
+	return fmt.Sprintf("http://localhost:%d/crud", s.port)
+}
+
+// Reset runs the setup of the server and serves the new handler, the server starts listening at the first reset
+func (s *Server) Reset() error {
+	handler, err := s.setup()
+	if err != nil {
+		return err
+	}
+	s.handler.Store(&handler)
+
+	if s.listener == nil {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+		if err != nil {
+			return err
+		}
+		s.listener = listener
+		fmt.Printf("Server %s is running on port %d\n", s.name, s.port)
+		go http.Serve(listener, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			(*s.handler.Load()).ServeHTTP(w, r)
+		}))
+	}
+	return nil
+}
+
+// tenantHeader is the header of the tenant of the requests
+const tenantHeader = "X-Tenant-Id"
+
+// registerModels registers the Employee model with the hooks the shared cases rely on, the Contract model,
+// and the Invoice model with the tenant of tenantHeader
+func registerModels(generator crud_generator.ICRUDGenerator) crud_generator.IModelRegistration {
+	generator.RegisterTenantResolver(func(r *http.Request) (any, error) {
+		if tenant := r.Header.Get(tenantHeader); tenant != "" {
+			return tenant, nil
+		}
+		return nil, nil
+	})
+	return generator.RegisterHooks(&models.Employee{}, crud_generator.Hooks{
+		AfterCreate: func(ctx context.Context, model *core.Model, record *map[string]any) error {
+			if (*record)["name"] == rollbackName {
+				return errors.New("the employee can't be created")
+			}
+			return nil
+		},
+	}).RegisterModel(&models.Employee{}).RegisterModel(&models.Contract{}).RegisterModel(&models.Invoice{})
+}
+
+// migrate recreates the tables of the models of registerModels
+func migrate(db *gorm.DB) error {
+	if err := db.Migrator().DropTable(&models.Invoice{}, &models.Contract{}, &models.Employee{}); err != nil {
+		return err
+	}
+	return db.AutoMigrate(&models.Employee{}, &models.Contract{}, &models.Invoice{})
+}
+
+// NewGormServer returns the server of the gorm repository mounted on chi
+func NewGormServer(db *gorm.DB, port int) *Server {
+	return NewServer("gorm", port, func() (http.Handler, error) {
+		if err := migrate(db); err != nil {
+			return nil, err
+		}
+		r := chi.NewRouter()
+		registerModels(crud_generator.NewCRUDGenerator(r, db)).Run()
+		return r, nil
+	})
+}
+
+// NewServeMuxServer returns the server of the gorm repository mounted on a http.ServeMux by its adapter
+func NewServeMuxServer(db *gorm.DB, port int) *Server {
+	return NewServer("http.ServeMux", port, func() (http.Handler, error) {
+		if err := migrate(db); err != nil {
+			return nil, err
+		}
+		mux := http.NewServeMux()
+		registerModels(crud_generator.NewCRUDGenerator(crud_generator.ServeMux(mux), db)).Run()
+		return mux, nil
+	})
+}
+
+// NewEchoServer returns the server of the gorm repository mounted on echo
+func NewEchoServer(db *gorm.DB, port int) *Server {
+	return NewServer("echo", port, func() (http.Handler, error) {
+		if err := migrate(db); err != nil {
+			return nil, err
+		}
+		e := echo.New()
+		crudecho.Register(e.Group(""), registerModels(crud_generator.NewCRUDGenerator(nil, db)))
+		return e, nil
+	})
+}
+
+// NewGinServer returns the server of the gorm repository mounted on gin, the employees have a birthday action
+// which adds a year to their age, and a card action writing a birthday card as text
+func NewGinServer(db *gorm.DB, port int) *Server {
+	gin.SetMode(gin.ReleaseMode)
+	return NewServer("gin", port, func() (http.Handler, error) {
+		if err := migrate(db); err != nil {
+			return nil, err
+		}
+		engine := gin.New()
+		generator := registerModels(crud_generator.NewCRUDGenerator(nil, db)).
+			RegisterAction(&models.Employee{}, "birthday", http.MethodPost,
+				func(w http.ResponseWriter, r *http.Request, action *crud_generator.ActionContext) (any, error) {
+					if action.Record == nil {
+						return nil, errs.BadRequest("the id of the employee is required", nil)
+					}
+					age, _ := (*action.Record)["age"].(int64)
+					return action.Service.Update(r.Context(), action.Model,
+						&map[string]any{"age": age + 1}, fmt.Sprint((*action.Record)["id"]))
+				}).
+			RegisterAction(&models.Employee{}, "card", http.MethodGet,
+				func(w http.ResponseWriter, r *http.Request, action *crud_generator.ActionContext) (any, error) {
+					if action.Record == nil {
+						return nil, errs.BadRequest("the id of the employee is required", nil)
+					}
+					w.Header().Set("Content-Type", "text/plain")
+					_, err := fmt.Fprintf(w, "Happy birthday %v", (*action.Record)["name"])
+					return nil, err
+				})
+		crudgin.Register(engine.Group(""), generator)
+		return engine, nil
+	})
+}
+
+// NewTypedServer returns the server of the gorm repository with the typed accounts, their secret can only be
+// written by the role hr and the role of the requests is read from the header X-Role. The typed badges must
+// have a label, then their label is upper cased by an untyped hook
+func NewTypedServer(db *gorm.DB, port int) *Server {
+	return NewServer("typed", port, func() (http.Handler, error) {
+		if err := db.Migrator().DropTable(&models.Account{}, &models.Badge{}); err != nil {
+			return nil, err
+		}
+		if err := db.AutoMigrate(&models.Account{}, &models.Badge{}); err != nil {
+			return nil, err
+		}
+		generator := crud_generator.NewCRUDGenerator(nil, db).
+			RegisterFieldPermission(&models.Account{}, "Secret", nil, []string{"hr"}).
+			RegisterRoleResolver(func(ctx context.Context) string {
+				role, _ := ctx.Value(roleKey{}).(string)
+				return role
+			})
+		crud_generator.Register[models.Account](generator)
+		crud_generator.Register[models.Badge](generator).Validate(func(ctx context.Context, badge *models.Badge) error {
+			if badge.Label == "" {
+				return errs.Validation("invalid input", nil).WithField("label", "label is required")
+			}
+			return nil
+		})
+		generator.RegisterHooks(&models.Badge{}, crud_generator.Hooks{
+			BeforeCreate: func(ctx context.Context, model *core.Model, payload *map[string]any) error {
+				if label, ok := (*payload)["label"].(string); ok {
+					(*payload)["label"] = strings.ToUpper(label)
+				}
+				return nil
+			},
+		})
+		return withRole(generator.Handler()), nil
+	})
+}
+
+type roleKey struct{}
+
+// withRole puts the role of the header X-Role into the context of the requests
+func withRole(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), roleKey{}, r.Header.Get("X-Role"))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// NewMemoryServer returns the server of the in-memory repository
+func NewMemoryServer(port int) *Server {
+	return NewServer("memory", port, func() (http.Handler, error) {
+		generator := crud_generator.NewCRUDGenerator(nil, nil,
+			crud_generator.WithDefaultRepository(repositories.NewMemoryRepository()))
+		registerModels(generator)
+		return generator.Handler(), nil
+	})
+}
+
+// NewModelRepositoryServer returns the server of the gorm repository where the employees have their own
+// in-memory repository, the transactions of their hooks must be opened on it
+func NewModelRepositoryServer(db *gorm.DB, port int) *Server {
+	return NewServer("the repository of the model", port, func() (http.Handler, error) {
+		if err := migrate(db); err != nil {
+			return nil, err
+		}
+		generator := crud_generator.NewCRUDGenerator(nil, db)
+		registerModels(generator).RegisterRepository(&models.Employee{}, repositories.NewMemoryRepository())
+		return generator.Handler(), nil
+	})
+}
+
+// scopedAge is the age of the oldest employees in the scope of the scope server
+const scopedAge = 40
+
+// NewScopeServer returns the server of the gorm repository where only the employees younger than scopedAge
+// are in the scope
+func NewScopeServer(db *gorm.DB, port int) *Server {
+	return NewServer("scopes", port, func() (http.Handler, error) {
+		if err := migrate(db); err != nil {
+			return nil, err
+		}
+		generator := crud_generator.NewCRUDGenerator(nil, db)
+		registerModels(generator).RegisterScope(&models.Employee{}, func(ctx context.Context, db *gorm.DB) *gorm.DB {
+			return db.Where("age < ?", scopedAge)
+		})
+		return generator.Handler(), nil
+	})
+}
+
+// subjectHeader is the header of the employee id of the requests of the authorizer server
+const subjectHeader = "X-Employee-Id"
+
+// contractPolicy lets the employees read and change only their own contracts
+type contractPolicy struct{}
+
+func (contractPolicy) Can(ctx context.Context, subject any, model *core.Model, action core.Action,
+	record *map[string]any) error {
+	if model.Name != "Contract" || record == nil || action == core.ActionCreate {
+		return nil
+	}
+	if fmt.Sprint((*record)["employee_id"]) != subject {
+		return errors.New("the contract isn't yours")
+	}
+	return nil
+}
+
+func (contractPolicy) ListScope(ctx context.Context, subject any, model *core.Model) core.Scope {
+	if model.Name != "Contract" {
+		return nil
+	}
+	return func(ctx context.Context, db *gorm.DB) *gorm.DB {
+		return db.Where("employee_id = ?", subject)
+	}
+}
+
+// NewAuthorizerServer returns the server of the gorm repository where the contracts are authorized by
+// contractPolicy for the employee of subjectHeader
+func NewAuthorizerServer(db *gorm.DB, port int) *Server {
+	return NewServer("authorizer", port, func() (http.Handler, error) {
+		if err := migrate(db); err != nil {
+			return nil, err
+		}
+		generator := crud_generator.NewCRUDGenerator(nil, db).
+			RegisterAuthorizer(contractPolicy{}, func(r *http.Request) (any, error) {
+				return r.Header.Get(subjectHeader), nil
+			})
+		registerModels(generator)
+		return generator.Handler(), nil
+	})
+}
+
+// NewReadOnlyServer returns the server of the gorm repository where the contracts are read only
+func NewReadOnlyServer(db *gorm.DB, port int) *Server {
+	return NewServer("read only", port, func() (http.Handler, error) {
+		if err := migrate(db); err != nil {
+			return nil, err
+		}
+		generator := crud_generator.NewCRUDGenerator(nil, db)
+		generator.RegisterModel(&models.Contract{}, crud_generator.ReadOnly())
+		return generator.Handler(), nil
+	})
+}
+
+// modelHeader is the header set by the middleware of the employees of the registration server
+const modelHeader = "X-Model"
+
+// NewRegistrationServer returns the server of the gorm repository where the employees have their own
+// middleware, setting modelHeader, and their own DTO of get detail, wrapping the record into "employee"
+func NewRegistrationServer(db *gorm.DB, port int) *Server {
+	return NewServer("registration", port, func() (http.Handler, error) {
+		if err := migrate(db); err != nil {
+			return nil, err
+		}
+		generator := crud_generator.NewCRUDGenerator(nil, db)
+		generator.RegisterModel(&models.Employee{}).
+			WithMiddleware(func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set(modelHeader, "Employee")
+					next.ServeHTTP(w, r)
+				})
+			}).
+			WithDTOGetDetail(func(w http.ResponseWriter, r *http.Request, ref any) any {
+				return map[string]any{"employee": ref}
+			}).
+			RegisterModel(&models.Contract{})
+		return generator.Handler(), nil
+	})
+}
+
+// adminPath is the base path of the second generator of the two generators server
+const adminPath = "/admin"
+
+// NewTwoGeneratorsServer returns the server of two generators on the same database, the first one is
+// registerModels on the default base path, the second one only has the employees, without hooks, on adminPath
+func NewTwoGeneratorsServer(db *gorm.DB, port int) *Server {
+	return NewServer("two generators", port, func() (http.Handler, error) {
+		if err := migrate(db); err != nil {
+			return nil, err
+		}
+		first := registerModels(crud_generator.NewCRUDGenerator(nil, db))
+		second := crud_generator.NewCRUDGenerator(nil, db, crud_generator.WithBasePath(adminPath))
+		second.RegisterModel(&models.Employee{})
+
+		mux := http.NewServeMux()
+		mux.Handle(crud_generator.DefaultBasePath+"/", first.Handler())
+		mux.Handle(adminPath+"/", second.Handler())
+		return mux, nil
+	})
+}
+
+// apiPath is the base path of the naming server
+const apiPath = "/api/v1"
+
+// NewNamingServer returns the server of the gorm repository on apiPath, where the job titles are named
+// in kebab case and the employees are aliased as staff
+func NewNamingServer(db *gorm.DB, port int) *Server {
+	return NewServer("naming", port, func() (http.Handler, error) {
+		if err := migrate(db); err != nil {
+			return nil, err
+		}
+		if err := db.Migrator().DropTable(&models.JobTitle{}); err != nil {
+			return nil, err
+		}
+		if err := db.AutoMigrate(&models.JobTitle{}); err != nil {
+			return nil, err
+		}
+		generator := crud_generator.NewCRUDGenerator(nil, db,
+			crud_generator.WithBasePath(apiPath+"/"), crud_generator.WithNamingStrategy(crud_generator.KebabCase))
+		generator.RegisterModel(&models.JobTitle{}).
+			RegisterModel(&models.Employee{}, crud_generator.Alias("staff"))
+		return generator.Handler(), nil
+	})
+}
+
+// LogRecorder keeps the json logs of a generator
+type LogRecorder struct {
+	mu    sync.Mutex
+	lines []map[string]any
+}
+
+func (l *LogRecorder) Write(p []byte) (int, error) {
+	var line map[string]any
+	if err := json.Unmarshal(p, &line); err != nil {
+		return 0, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, line)
+	return len(p), nil
+}
+
+// Find returns the last log of the requests of path
+func (l *LogRecorder) Find(path string) (map[string]any, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := len(l.lines) - 1; i >= 0; i-- {
+		if l.lines[i]["path"] == path {
+			return l.lines[i], true
+		}
+	}
+	return nil, false
+}
+
+func (l *LogRecorder) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = nil
+}
+
+// queryTimeout is the timeout of the requests of the timeout server
+const queryTimeout = 200 * time.Millisecond
+
+// NewTimeoutServer returns the server of the gorm repository with queryTimeout, creating a contract waits
+// until the request is cancelled, the logs of the generator are written into recorder
+func NewTimeoutServer(db *gorm.DB, recorder *LogRecorder, port int) *Server {
+	return NewServer("timeout", port, func() (http.Handler, error) {
+		if err := migrate(db); err != nil {
+			return nil, err
+		}
+		recorder.Reset()
+		logger := slog.New(slog.NewJSONHandler(recorder, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		generator := crud_generator.NewCRUDGenerator(nil, db,
+			crud_generator.WithQueryTimeout(queryTimeout), crud_generator.WithLogger(logger))
+		registerModels(generator).RegisterHooks(&models.Contract{}, crud_generator.Hooks{
+			BeforeCreate: func(ctx context.Context, model *core.Model, inputData *map[string]any) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		})
+		return generator.Handler(), nil
+	})
+}
+
+// NewComputedServer returns the server of repository where the employees have the computed field initial,
+// the first letter of their name, and the integer expression name_length, the gorm repository is used
+// when repository is nil
+func NewComputedServer(name string, db *gorm.DB, repository repositories.IRepository, port int) *Server {
+	return NewServer(name, port, func() (http.Handler, error) {
+		if err := migrate(db); err != nil {
+			return nil, err
+		}
+		var opts []crud_generator.GeneratorOption
+		if repository != nil {
+			opts = append(opts, crud_generator.WithDefaultRepository(repository))
+		}
+		generator := crud_generator.NewCRUDGenerator(nil, db, opts...)
+		registerModels(generator).
+			RegisterComputedField(&models.Employee{}, "initial", func(ctx context.Context, record map[string]any) (any, error) {
+				name, _ := record["name"].(string)
+				return name[:1], nil
+			}).
+			RegisterComputedExpr(&models.Employee{}, "name_length", "length(name)", crud_generator.ExprType("INTEGER"))
+		return generator.Handler(), nil
+	})
+}
+
+// NewTracingServer returns the server of the gorm repository recording its spans into exporter,
+// the spans are reset with the store
+func NewTracingServer(db *gorm.DB, exporter *tracetest.InMemoryExporter, port int) *Server {
+	return NewServer("tracing", port, func() (http.Handler, error) {
+		if err := migrate(db); err != nil {
+			return nil, err
+		}
+		exporter.Reset()
+		generator := crud_generator.NewCRUDGenerator(nil, db,
+			crudotel.WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)),
+				crudotel.WithPropagator(propagation.TraceContext{})))
+		registerModels(generator)
+		return generator.Handler(), nil
+	})
+}
+
+// NewSQLServer returns the server of the database/sql repository with the placeholders of dialect,
+// sqlite understands the placeholders of both dialects
+func NewSQLServer(db *gorm.DB, sqlDB *sql.DB, dialect repositories.Dialect, port int) *Server {
+	return NewServer("database/sql with "+string(dialect)+" placeholders", port, func() (http.Handler, error) {
+		if err := migrate(db); err != nil {
+			return nil, err
+		}
+		generator := crud_generator.NewCRUDGenerator(nil, db,
+			crud_generator.WithDefaultRepository(repositories.NewSQLRepository(sqlDB, dialect)))
+		registerModels(generator)
+		return generator.Handler(), nil
+	})
+}
+
+// NewBoltServer returns the server of the bbolt repository storing its records in the file of path,
+// the file is removed at each reset
+func NewBoltServer(path string, port int) *Server {
+	var db *bolt.DB
+	return NewServer("bbolt", port, func() (http.Handler, error) {
+		if db != nil {
+			if err := db.Close(); err != nil {
+				return nil, err
+			}
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return nil, err
+		}
+		var err error
+		if db, err = bolt.Open(path, 0600, nil); err != nil {
+			return nil, err
+		}
+		generator := crud_generator.NewCRUDGenerator(nil, nil,
+			crud_generator.WithDefaultRepository(repositories.NewBoltRepository(db)))
+		registerModels(generator)
+		return generator.Handler(), nil
+	})
+}
@@ -0,0 +1,903 @@
+package testcases
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	crud_generator "github.com/duytacong24895/go-crud-generator"
+	"github.com/duytacong24895/go-crud-generator/core"
+	"github.com/duytacong24895/go-crud-generator/errs"
+	"github.com/duytacong24895/go-crud-generator/repositories"
+
+	"github.com/duytacong24895/go-crud-generator/tests/pkg"
+	dummiesdata "github.com/duytacong24895/go-crud-generator/tests/pkg/dummies_data"
+	"github.com/go-resty/resty/v2"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const (
+	traceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentID    = "00f067aa0ba902b7"
+	traceParent = "00-" + traceID + "-" + parentID + "-01"
+)
+
+// employees are created in this order by the cases, so their ids are 1, 2 and 3
+var employees = []map[string]any{
+	dummiesdata.Employee_NormalCaseCreateEmployee,
+	dummiesdata.Employee_NormalCaseCreateEmployee2,
+	dummiesdata.Employee_NormalCaseCreateEmployee3,
+}
+
+// NewSuite returns the cases shared by every server, the servers must answer them the same way
+func NewSuite(server *Server) []pkg.ITestCase {
+	return []pkg.ITestCase{
+		newServerCase(server, "Create Employee", nil, func(client *resty.Client) (any, error) {
+			var created, detail map[string]any
+			status, err := pkg.Request(client, http.MethodPost, "/Employee", employees[0], &created)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := pkg.Request(client, http.MethodGet, fmt.Sprintf("/Employee/%v", created["id"]), nil, &detail); err != nil {
+				return nil, err
+			}
+			return map[string]any{"status": status, "created": summary(created), "detail": summary(detail)}, nil
+		}, map[string]any{"status": http.StatusCreated, "created": expected(1, employees[0]), "detail": expected(1, employees[0])}),
+
+		newServerCase(server, "Get detail Employee", employees, func(client *resty.Client) (any, error) {
+			var detail map[string]any
+			status, err := pkg.Request(client, http.MethodGet, "/Employee/2", nil, &detail)
+			return map[string]any{"status": status, "detail": summary(detail)}, err
+		}, map[string]any{"status": http.StatusOK, "detail": expected(2, employees[1])}),
+
+		newServerCase(server, "Get list Employee with filter and order_by", employees, func(client *resty.Client) (any, error) {
+			return list(client, `["age","gt","11"]`, "age desc")
+		}, []map[string]any{expected(3, employees[2]), expected(1, employees[0])}),
+
+		newServerCase(server, "Get list Employee with nested filter", employees, func(client *resty.Client) (any, error) {
+			return list(client, `[["age","eq","11"],"_or",["name","eq","Duy3"]]`, "id")
+		}, []map[string]any{expected(2, employees[1]), expected(3, employees[2])}),
+
+		newServerCase(server, "Update Employee", employees[:1], func(client *resty.Client) (any, error) {
+			var updated, detail map[string]any
+			status, err := pkg.Request(client, http.MethodPut, "/Employee/1", dummiesdata.Employee_NormalCaseUpdateEmployee, &updated)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := pkg.Request(client, http.MethodGet, "/Employee/1", nil, &detail); err != nil {
+				return nil, err
+			}
+			return map[string]any{"status": status, "updated": summary(updated), "detail": summary(detail)}, nil
+		}, func() any {
+			employee := maps.Clone(employees[0])
+			maps.Copy(employee, dummiesdata.Employee_NormalCaseUpdateEmployee)
+			return map[string]any{"status": http.StatusOK, "updated": expected(1, employee), "detail": expected(1, employee)}
+		}()),
+
+		newServerCase(server, "Delete Employee", employees, func(client *resty.Client) (any, error) {
+			status, err := pkg.Request(client, http.MethodDelete, "/Employee/1", nil, nil)
+			if err != nil {
+				return nil, err
+			}
+			detailStatus, err := pkg.Request(client, http.MethodGet, "/Employee/1", nil, nil)
+			if err != nil {
+				return nil, err
+			}
+			records, err := list(client, "", "id")
+			return map[string]any{"status": status, "detail": detailStatus, "list": records}, err
+		}, map[string]any{"status": http.StatusOK, "detail": http.StatusNotFound,
+			"list": []map[string]any{expected(2, employees[1]), expected(3, employees[2])}}),
+
+		newServerCase(server, "Create Employee with an existing id", employees[:1], func(client *resty.Client) (any, error) {
+			employee := maps.Clone(employees[1])
+			employee["id"] = 1
+			status, err := pkg.Request(client, http.MethodPost, "/Employee", employee, nil)
+			return status, err
+		}, http.StatusConflict),
+
+		newServerCase(server, "Soft delete Contract", employees[:1], func(client *resty.Client) (any, error) {
+			contract := map[string]any{"id": 1, "employee_id": 1, "title": "developer"}
+			if _, err := pkg.Request(client, http.MethodPost, "/Contract", contract, nil); err != nil {
+				return nil, err
+			}
+			status, err := pkg.Request(client, http.MethodDelete, "/Contract/1", nil, nil)
+			if err != nil {
+				return nil, err
+			}
+			detail, err := pkg.Request(client, http.MethodGet, "/Contract/1", nil, nil)
+			if err != nil {
+				return nil, err
+			}
+			var contracts []map[string]any
+			if _, err := pkg.Request(client, http.MethodGet, "/Contract?page=1&page_size=10", nil, &contracts); err != nil {
+				return nil, err
+			}
+			// The deleted contract is kept, so its id can't be reused
+			recreated, err := pkg.Request(client, http.MethodPost, "/Contract", contract, nil)
+			return map[string]any{"status": status, "detail": detail, "list": len(contracts), "recreated": recreated}, err
+		}, map[string]any{"status": http.StatusOK, "detail": http.StatusNotFound, "list": 0, "recreated": http.StatusConflict}),
+
+		newServerCase(server, "Get list of an unknown model", nil, func(client *resty.Client) (any, error) {
+			resp, err := pkg.Send(client, http.MethodGet, "/Nothing", nil)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{"status": resp.StatusCode(), "type": resp.Header().Get("Content-Type")}, nil
+		}, map[string]any{"status": http.StatusNotFound, "type": "application/problem+json"}),
+
+		newServerCase(server, "Roll back Create Employee when a hook fails", nil, func(client *resty.Client) (any, error) {
+			employee := maps.Clone(employees[0])
+			employee["Name"] = rollbackName
+			status, err := pkg.Request(client, http.MethodPost, "/Employee", employee, nil)
+			if err != nil {
+				return nil, err
+			}
+			records, err := list(client, "", "")
+			return map[string]any{"status": status, "list": len(records)}, err
+		}, map[string]any{"status": http.StatusInternalServerError, "list": 0}),
+	}
+}
+
+// NewTenantSuite returns the cases of the invoices of the tenants, the tenant of the requests is
+// read from tenantHeader
+func NewTenantSuite(server *Server) []pkg.ITestCase {
+	tenant := func(id string) *resty.Client {
+		return pkg.NewHTTPClient(server.Url()).SetHeader(tenantHeader, id)
+	}
+	create := func(title, tenantID string) (map[string]any, error) {
+		var created map[string]any
+		status, err := pkg.Request(tenant("a"), http.MethodPost, "/Invoice", map[string]any{"title": title, "tenant_id": tenantID}, &created)
+		if err == nil && status != http.StatusCreated {
+			err = fmt.Errorf("creating the invoice responded %d", status)
+		}
+		return created, err
+	}
+
+	return []pkg.ITestCase{
+		newServerCase(server, "Create Invoice for another tenant", nil, func(client *resty.Client) (any, error) {
+			created, err := create("first", "b")
+			if err != nil {
+				return nil, err
+			}
+			return created["tenant_id"], nil
+		}, "a"),
+
+		newServerCase(server, "Get Invoice of another tenant", nil, func(client *resty.Client) (any, error) {
+			if _, err := create("first", ""); err != nil {
+				return nil, err
+			}
+			own, err := pkg.Request(tenant("a"), http.MethodGet, "/Invoice/1", nil, nil)
+			if err != nil {
+				return nil, err
+			}
+			other, err := pkg.Request(tenant("b"), http.MethodGet, "/Invoice/1", nil, nil)
+			if err != nil {
+				return nil, err
+			}
+			var invoices []map[string]any
+			if _, err := pkg.Request(tenant("b"), http.MethodGet, "/Invoice?page=1&page_size=10", nil, &invoices); err != nil {
+				return nil, err
+			}
+			return map[string]any{"own": own, "other": other, "list": len(invoices)}, nil
+		}, map[string]any{"own": http.StatusOK, "other": http.StatusNotFound, "list": 0}),
+
+		newServerCase(server, "Get list Invoice without a tenant", nil, func(client *resty.Client) (any, error) {
+			if _, err := create("first", ""); err != nil {
+				return nil, err
+			}
+			return pkg.Request(client, http.MethodGet, "/Invoice?page=1&page_size=10", nil, nil)
+		}, http.StatusForbidden),
+
+		newServerCase(server, "Update Invoice to another tenant", nil, func(client *resty.Client) (any, error) {
+			if _, err := create("first", ""); err != nil {
+				return nil, err
+			}
+			var updated map[string]any
+			status, err := pkg.Request(tenant("a"), http.MethodPut, "/Invoice/1", map[string]any{"title": "second", "tenant_id": "b"}, &updated)
+			if err != nil {
+				return nil, err
+			}
+			other, err := pkg.Request(tenant("b"), http.MethodGet, "/Invoice/1", nil, nil)
+			return map[string]any{"status": status, "title": updated["title"], "tenant": updated["tenant_id"], "other": other}, err
+		}, map[string]any{"status": http.StatusOK, "title": "second", "tenant": "a", "other": http.StatusNotFound}),
+	}
+}
+
+// NewMethodSuite returns the cases of the methods which no route is mounted on, the server must serve
+// the handler of the generator as the routers have their own responses
+func NewMethodSuite(server *Server) []pkg.ITestCase {
+	return []pkg.ITestCase{
+		newServerCase(server, "Patch Employee", employees[:1], func(client *resty.Client) (any, error) {
+			resp, err := pkg.Send(client, http.MethodPatch, "/Employee/1", map[string]any{"age": 1})
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{"status": resp.StatusCode(), "type": resp.Header().Get("Content-Type"),
+				"allow": resp.Header().Get("Allow")}, nil
+		}, map[string]any{"status": http.StatusMethodNotAllowed, "type": "application/problem+json",
+			"allow": "GET, PUT, DELETE"}),
+
+		newServerCase(server, "Patch an unknown model", nil, func(client *resty.Client) (any, error) {
+			resp, err := pkg.Send(client, http.MethodPatch, "/Nothing/1", map[string]any{"age": 1})
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{"status": resp.StatusCode(), "type": resp.Header().Get("Content-Type")}, nil
+		}, map[string]any{"status": http.StatusNotFound, "type": "application/problem+json"}),
+	}
+}
+
+// NewReadOnlySuite returns the cases of the read only contracts, the writes respond 405 with the methods
+// which are still allowed
+func NewReadOnlySuite(server *Server) []pkg.ITestCase {
+	send := func(method, path string) (any, error) {
+		resp, err := pkg.Send(pkg.NewHTTPClient(server.Url()), method, path, map[string]any{"title": "developer"})
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"status": resp.StatusCode(), "allow": resp.Header().Get("Allow")}, nil
+	}
+	return []pkg.ITestCase{
+		newServerCase(server, "Get list read only Contract", nil, func(client *resty.Client) (any, error) {
+			return pkg.Request(client, http.MethodGet, "/Contract?page=1&page_size=10", nil, nil)
+		}, http.StatusOK),
+
+		newServerCase(server, "Create read only Contract", nil, func(client *resty.Client) (any, error) {
+			return send(http.MethodPost, "/Contract")
+		}, map[string]any{"status": http.StatusMethodNotAllowed, "allow": "GET"}),
+
+		newServerCase(server, "Update and delete read only Contract", nil, func(client *resty.Client) (any, error) {
+			updated, err := send(http.MethodPut, "/Contract/1")
+			if err != nil {
+				return nil, err
+			}
+			deleted, err := send(http.MethodDelete, "/Contract/1")
+			return map[string]any{"update": updated, "delete": deleted}, err
+		}, map[string]any{
+			"update": map[string]any{"status": http.StatusMethodNotAllowed, "allow": "GET"},
+			"delete": map[string]any{"status": http.StatusMethodNotAllowed, "allow": "GET"},
+		}),
+	}
+}
+
+// NewRegistrationSuite returns the cases of the middleware and the DTO registered for the employees only
+func NewRegistrationSuite(server *Server) []pkg.ITestCase {
+	detail := func(client *resty.Client, path string) (map[string]any, error) {
+		resp, err := pkg.Send(client, http.MethodGet, path, nil)
+		if err != nil {
+			return nil, err
+		}
+		var body map[string]any
+		if err := json.Unmarshal(resp.Body(), &body); err != nil {
+			return nil, err
+		}
+		_, wrapped := body["employee"]
+		return map[string]any{"status": resp.StatusCode(), "header": resp.Header().Get(modelHeader), "wrapped": wrapped}, nil
+	}
+	return []pkg.ITestCase{
+		newServerCase(server, "Get detail Employee with its middleware and DTO", employees[:1], func(client *resty.Client) (any, error) {
+			if _, err := pkg.Request(client, http.MethodPost, "/Contract", map[string]any{"employee_id": 1, "title": "developer"}, nil); err != nil {
+				return nil, err
+			}
+			employee, err := detail(client, "/Employee/1")
+			if err != nil {
+				return nil, err
+			}
+			contract, err := detail(client, "/Contract/1")
+			return map[string]any{"employee": employee, "contract": contract}, err
+		}, map[string]any{
+			"employee": map[string]any{"status": http.StatusOK, "header": "Employee", "wrapped": true},
+			"contract": map[string]any{"status": http.StatusOK, "header": "", "wrapped": false},
+		}),
+	}
+}
+
+// NewTwoGeneratorsSuite returns the cases of the two generators server, each generator has its own models
+// and hooks but they share the records of the database
+func NewTwoGeneratorsSuite(server *Server) []pkg.ITestCase {
+	admin := func() *resty.Client {
+		return pkg.NewHTTPClient(strings.TrimSuffix(server.Url(), crud_generator.DefaultBasePath) + adminPath)
+	}
+	return []pkg.ITestCase{
+		newServerCase(server, "Get detail Employee created by the other generator", employees[:1], func(client *resty.Client) (any, error) {
+			var detail map[string]any
+			status, err := pkg.Request(admin(), http.MethodGet, "/Employee/1", nil, &detail)
+			return map[string]any{"status": status, "detail": summary(detail)}, err
+		}, map[string]any{"status": http.StatusOK, "detail": expected(1, employees[0])}),
+
+		newServerCase(server, "Get list of a model of the other generator", nil, func(client *resty.Client) (any, error) {
+			own, err := pkg.Request(client, http.MethodGet, "/Contract?page=1&page_size=10", nil, nil)
+			if err != nil {
+				return nil, err
+			}
+			other, err := pkg.Request(admin(), http.MethodGet, "/Contract?page=1&page_size=10", nil, nil)
+			return map[string]any{"own": own, "other": other}, err
+		}, map[string]any{"own": http.StatusOK, "other": http.StatusNotFound}),
+
+		newServerCase(server, "Create Employee without the hooks of the other generator", nil, func(client *resty.Client) (any, error) {
+			employee := maps.Clone(employees[0])
+			employee["Name"] = rollbackName
+			hooked, err := pkg.Request(client, http.MethodPost, "/Employee", employee, nil)
+			if err != nil {
+				return nil, err
+			}
+			plain, err := pkg.Request(admin(), http.MethodPost, "/Employee", employee, nil)
+			return map[string]any{"hooked": hooked, "plain": plain}, err
+		}, map[string]any{"hooked": http.StatusInternalServerError, "plain": http.StatusCreated}),
+	}
+}
+
+// NewNamingSuite returns the cases of the urls of the naming server, they are all under apiPath
+func NewNamingSuite(server *Server) []pkg.ITestCase {
+	api := func() *resty.Client {
+		return pkg.NewHTTPClient(strings.TrimSuffix(server.Url(), crud_generator.DefaultBasePath) + apiPath)
+	}
+	return []pkg.ITestCase{
+		newServerCase(server, "Create JobTitle on its kebab case url", nil, func(client *resty.Client) (any, error) {
+			resp, err := pkg.Send(api(), http.MethodPost, "/job-titles", map[string]any{"name": "developer"})
+			if err != nil {
+				return nil, err
+			}
+			var detail map[string]any
+			detailStatus, err := pkg.Request(api(), http.MethodGet, "/Job-Titles/1", nil, &detail)
+			return map[string]any{"status": resp.StatusCode(), "location": resp.Header().Get("Location"),
+				"detail": detailStatus, "name": detail["name"]}, err
+		}, map[string]any{"status": http.StatusCreated, "location": apiPath + "/job-titles/1",
+			"detail": http.StatusOK, "name": "developer"}),
+
+		newServerCase(server, "Get list JobTitle on the other urls", nil, func(client *resty.Client) (any, error) {
+			structName, err := pkg.Request(api(), http.MethodGet, "/JobTitle?page=1&page_size=10", nil, nil)
+			if err != nil {
+				return nil, err
+			}
+			defaultPath, err := pkg.Request(client, http.MethodGet, "/job-titles?page=1&page_size=10", nil, nil)
+			return map[string]any{"struct name": structName, "default path": defaultPath}, err
+		}, map[string]any{"struct name": http.StatusNotFound, "default path": http.StatusNotFound}),
+
+		newServerCase(server, "Get list Employee on its alias", nil, func(client *resty.Client) (any, error) {
+			alias, err := pkg.Request(api(), http.MethodGet, "/staff?page=1&page_size=10", nil, nil)
+			if err != nil {
+				return nil, err
+			}
+			table, err := pkg.Request(api(), http.MethodGet, "/employee?page=1&page_size=10", nil, nil)
+			return map[string]any{"alias": alias, "table": table}, err
+		}, map[string]any{"alias": http.StatusOK, "table": http.StatusNotFound}),
+	}
+}
+
+// NewTimeoutSuite returns the cases of the contracts of the timeout server, their creation only ends when
+// the request is cancelled by its timeout or by the client
+func NewTimeoutSuite(server *Server, recorder *LogRecorder) []pkg.ITestCase {
+	return []pkg.ITestCase{
+		newServerCase(server, "Create Contract exceeding the query timeout", employees[:1], func(client *resty.Client) (any, error) {
+			resp, err := pkg.Send(client, http.MethodPost, "/Contract", map[string]any{"employee_id": 1, "title": "developer"})
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{"status": resp.StatusCode(), "type": resp.Header().Get("Content-Type")}, nil
+		}, map[string]any{"status": http.StatusGatewayTimeout, "type": "application/problem+json"}),
+
+		newServerCase(server, "Create Contract cancelled by the client", employees[:1], func(client *resty.Client) (any, error) {
+			client.SetTimeout(queryTimeout / 4).SetRetryCount(0)
+			if _, err := pkg.Send(client, http.MethodPost, "/Contract", map[string]any{"employee_id": 1, "title": "developer"}); err == nil {
+				return nil, errors.New("the request wasn't cancelled by the client")
+			}
+			// The request is logged once the handler returns
+			for start := time.Now(); time.Since(start) < 10*queryTimeout; time.Sleep(queryTimeout / 10) {
+				if entry, ok := recorder.Find(crud_generator.DefaultBasePath + "/Contract"); ok {
+					return map[string]any{"level": entry["level"], "status": entry["status"]}, nil
+				}
+			}
+			return nil, errors.New("the request wasn't logged")
+		}, map[string]any{"level": slog.LevelDebug.String(), "status": float64(errs.StatusClientClosedRequest)}),
+	}
+}
+
+// NewComputedSuite returns the cases of the computed fields of the computed server, the names of the employees
+// are Duy, Duy2 and Duy3
+func NewComputedSuite(server *Server) []pkg.ITestCase {
+	computed := func(client *resty.Client, path string) ([]any, error) {
+		var records []map[string]any
+		status, err := pkg.Request(client, http.MethodGet, path, nil, &records)
+		if err != nil {
+			return nil, err
+		}
+		if status != http.StatusOK {
+			return nil, fmt.Errorf("get list responded %d", status)
+		}
+		result := make([]any, 0, len(records))
+		for _, record := range records {
+			result = append(result, []any{record["id"], record["initial"], record["name_length"]})
+		}
+		return result, nil
+	}
+	return []pkg.ITestCase{
+		newServerCase(server, "Get detail Employee with its computed fields", employees[:1], func(client *resty.Client) (any, error) {
+			var detail, narrowed map[string]any
+			if _, err := pkg.Request(client, http.MethodGet, "/Employee/1", nil, &detail); err != nil {
+				return nil, err
+			}
+			if _, err := pkg.Request(client, http.MethodGet, "/Employee/1?fields=id,name_length", nil, &narrowed); err != nil {
+				return nil, err
+			}
+			return map[string]any{"initial": detail["initial"], "name_length": detail["name_length"], "narrowed": narrowed}, nil
+		}, map[string]any{"initial": "D", "name_length": float64(3),
+			"narrowed": map[string]any{"id": float64(1), "name_length": float64(3)}}),
+
+		newServerCase(server, "Get list Employee filtered by a computed expression", employees, func(client *resty.Client) (any, error) {
+			params := url.Values{"page": {"1"}, "page_size": {"10"}, "filter": {`["name_length","gte","4"]`}, "order_by": {"id"}}
+			return computed(client, "/Employee?"+params.Encode())
+		}, []any{[]any{float64(2), "D", float64(4)}, []any{float64(3), "D", float64(4)}}),
+
+		newServerCase(server, "Get list Employee sorted by a computed expression", employees, func(client *resty.Client) (any, error) {
+			params := url.Values{"page": {"1"}, "page_size": {"10"}, "order_by": {"name_length desc, id desc"}}
+			return computed(client, "/Employee?"+params.Encode())
+		}, []any{[]any{float64(3), "D", float64(4)}, []any{float64(2), "D", float64(4)}, []any{float64(1), "D", float64(3)}}),
+	}
+}
+
+// NewScopeSuite returns the cases of the scope of the scope server, the third employee is out of it
+func NewScopeSuite(server *Server) []pkg.ITestCase {
+	return []pkg.ITestCase{
+		newServerCase(server, "Get list Employee in the scope", employees, func(client *resty.Client) (any, error) {
+			records, err := list(client, "", "id")
+			return ids(records), err
+		}, []any{float64(1), float64(2)}),
+
+		newServerCase(server, "Get detail Employee out of the scope", employees, func(client *resty.Client) (any, error) {
+			inScope, err := pkg.Request(client, http.MethodGet, "/Employee/1", nil, nil)
+			if err != nil {
+				return nil, err
+			}
+			outOfScope, err := pkg.Request(client, http.MethodGet, "/Employee/3", nil, nil)
+			return map[string]any{"in": inScope, "out": outOfScope}, err
+		}, map[string]any{"in": http.StatusOK, "out": http.StatusNotFound}),
+
+		newServerCase(server, "Update Employee out of the scope", employees, func(client *resty.Client) (any, error) {
+			return pkg.Request(client, http.MethodPut, "/Employee/3", map[string]any{"age": 20}, nil)
+		}, http.StatusNotFound),
+
+		newServerCase(server, "Delete Employee out of the scope", employees, func(client *resty.Client) (any, error) {
+			status, err := pkg.Request(client, http.MethodDelete, "/Employee/3", nil, nil)
+			if err != nil {
+				return nil, err
+			}
+			// The employee is still there, so its id can't be reused
+			employee := maps.Clone(employees[2])
+			employee["id"] = 3
+			recreated, err := pkg.Request(client, http.MethodPost, "/Employee", employee, nil)
+			return map[string]any{"status": status, "recreated": recreated}, err
+		}, map[string]any{"status": http.StatusNotFound, "recreated": http.StatusConflict}),
+
+		newServerCase(server, "Create Employee out of the scope", employees[:2], func(client *resty.Client) (any, error) {
+			var created map[string]any
+			status, err := pkg.Request(client, http.MethodPost, "/Employee", employees[2], &created)
+			if err != nil {
+				return nil, err
+			}
+			employee := maps.Clone(employees[2])
+			employee["id"] = 3
+			recreated, err := pkg.Request(client, http.MethodPost, "/Employee", employee, nil)
+			return map[string]any{"status": status, "created": summary(created), "recreated": recreated}, err
+		}, map[string]any{"status": http.StatusCreated, "created": expected(3, employees[2]), "recreated": http.StatusConflict}),
+
+		newServerCase(server, "Get list Employee with a filter escaping the scope", employees, func(client *resty.Client) (any, error) {
+			records, err := list(client, fmt.Sprintf(`[["name","eq","Duy"],"_or",["age","gte","%d"]]`, scopedAge), "id")
+			return ids(records), err
+		}, []any{float64(1)}),
+	}
+}
+
+// NewAuthorizerSuite returns the cases of the contracts of the first two employees on the authorizer server,
+// the first contract is the one of the first employee
+func NewAuthorizerSuite(server *Server) []pkg.ITestCase {
+	employee := func(id int) *resty.Client {
+		return pkg.NewHTTPClient(server.Url()).SetHeader(subjectHeader, fmt.Sprint(id))
+	}
+	seed := func() error {
+		for id := 1; id <= 2; id++ {
+			contract := map[string]any{"employee_id": id, "title": fmt.Sprintf("contract %d", id)}
+			status, err := pkg.Request(employee(id), http.MethodPost, "/Contract", contract, nil)
+			if err != nil {
+				return err
+			}
+			if status != http.StatusCreated {
+				return fmt.Errorf("creating the contract responded %d", status)
+			}
+		}
+		return nil
+	}
+
+	return []pkg.ITestCase{
+		newServerCase(server, "Get detail Contract of another employee", employees[:2], func(client *resty.Client) (any, error) {
+			if err := seed(); err != nil {
+				return nil, err
+			}
+			// The record is authorized before it's narrowed to the requested fields
+			own, err := pkg.Request(employee(1), http.MethodGet, "/Contract/1?fields=title", nil, nil)
+			if err != nil {
+				return nil, err
+			}
+			other, err := pkg.Request(employee(2), http.MethodGet, "/Contract/1", nil, nil)
+			return map[string]any{"own": own, "other": other}, err
+		}, map[string]any{"own": http.StatusOK, "other": http.StatusForbidden}),
+
+		newServerCase(server, "Update and delete Contract of another employee", employees[:2], func(client *resty.Client) (any, error) {
+			if err := seed(); err != nil {
+				return nil, err
+			}
+			updated, err := pkg.Request(employee(2), http.MethodPut, "/Contract/1", map[string]any{"title": "stolen"}, nil)
+			if err != nil {
+				return nil, err
+			}
+			deleted, err := pkg.Request(employee(2), http.MethodDelete, "/Contract/1", nil, nil)
+			if err != nil {
+				return nil, err
+			}
+			var detail map[string]any
+			_, err = pkg.Request(employee(1), http.MethodGet, "/Contract/1", nil, &detail)
+			return map[string]any{"updated": updated, "deleted": deleted, "title": detail["title"]}, err
+		}, map[string]any{"updated": http.StatusForbidden, "deleted": http.StatusForbidden, "title": "contract 1"}),
+
+		newServerCase(server, "Get list Contract narrowed to the employee", employees[:2], func(client *resty.Client) (any, error) {
+			if err := seed(); err != nil {
+				return nil, err
+			}
+			var contracts []map[string]any
+			if _, err := pkg.Request(employee(2), http.MethodGet, "/Contract?page=1&page_size=10", nil, &contracts); err != nil {
+				return nil, err
+			}
+			return ids(contracts), nil
+		}, []any{float64(2)}),
+	}
+}
+
+// NewSQLSuite returns the cases of the database/sql repository, the columns are written into its sql
+// so they are checked against the model, and the errors of the drivers are matched by their messages
+func NewSQLSuite(server *Server) []pkg.ITestCase {
+	return []pkg.ITestCase{
+		newServerCase(server, "Get list Employee ordered by an unknown column", employees, func(client *resty.Client) (any, error) {
+			params := url.Values{"page": {"1"}, "page_size": {"10"}, "order_by": {"name, salary desc"}}
+			status, err := pkg.Request(client, http.MethodGet, "/Employee?"+params.Encode(), nil, nil)
+			return status, err
+		}, http.StatusBadRequest),
+
+		newServerCase(server, "Get list Employee filtered by an unknown column", employees, func(client *resty.Client) (any, error) {
+			params := url.Values{"page": {"1"}, "page_size": {"10"}, "filter": {`["1=1 OR salary","eq","1"]`}}
+			status, err := pkg.Request(client, http.MethodGet, "/Employee?"+params.Encode(), nil, nil)
+			return status, err
+		}, http.StatusBadRequest),
+
+		newServerCase(server, "Create Contract violating its foreign key", employees[:1], func(client *resty.Client) (any, error) {
+			known, err := pkg.Request(client, http.MethodPost, "/Contract", map[string]any{"employee_id": 1, "title": "known"}, nil)
+			if err != nil {
+				return nil, err
+			}
+			unknown, err := pkg.Request(client, http.MethodPost, "/Contract", map[string]any{"employee_id": 99, "title": "unknown"}, nil)
+			return map[string]any{"known": known, "unknown": unknown}, err
+		}, map[string]any{"known": http.StatusCreated, "unknown": http.StatusConflict}),
+	}
+}
+
+// NewPlaceholderSuite returns the cases of the where clauses built with the placeholders of the dialects,
+// the placeholders are numbered after the args which are already bound
+func NewPlaceholderSuite() []pkg.ITestCase {
+	build := func(name string, dialect repositories.Dialect, expect any) pkg.ITestCase {
+		var actual any
+		return pkg.NewTestCase(&pkg.NewTestCaseDTO{
+			Name:      name,
+			Preparing: func() error { return nil },
+			Do: func() error {
+				filter := core.NewFilter()
+				if err := filter.Load(`[["age","bw","11::27"],"_or",[["name","eq","Duy"],"_and",["phone","_null",""]]]`); err != nil {
+					return err
+				}
+				clause, args, err := filter.BuildSQL(dialect.Placeholder, []any{"tenant"})
+				actual = map[string]any{"clause": clause, "args": args}
+				return err
+			},
+			GetExpected: func() (any, error) {
+				return expect, nil
+			},
+			GetActual: func() (any, error) {
+				return actual, nil
+			},
+		})
+	}
+	args := []any{"tenant", "11", "27", "Duy"}
+	return []pkg.ITestCase{
+		build("Build the where clause with the postgres placeholders", repositories.DialectPostgres, map[string]any{
+			"clause": "(age BETWEEN $2 AND $3 OR (name = $4 AND phone IS NULL))", "args": args}),
+		build("Build the where clause with the sqlite placeholders", repositories.DialectSQLite, map[string]any{
+			"clause": "(age BETWEEN ? AND ? OR (name = ? AND phone IS NULL))", "args": args}),
+	}
+}
+
+// NewBoltSuite returns the cases of the bbolt repository, the employees are filtered by the index of their age
+// so the index must follow the updates and the deletions
+func NewBoltSuite(server *Server) []pkg.ITestCase {
+	return []pkg.ITestCase{
+		newServerCase(server, "Get list Employee between ages by the index", employees, func(client *resty.Client) (any, error) {
+			return list(client, `["age","bw","11::27"]`, "id")
+		}, []map[string]any{expected(1, employees[0]), expected(2, employees[1])}),
+
+		newServerCase(server, "Get list Employee by the index after an update", employees, func(client *resty.Client) (any, error) {
+			if _, err := pkg.Request(client, http.MethodPut, "/Employee/1", dummiesdata.Employee_NormalCaseUpdateEmployee, nil); err != nil {
+				return nil, err
+			}
+			previous, err := list(client, `["age","eq","27"]`, "id")
+			if err != nil {
+				return nil, err
+			}
+			current, err := list(client, `["age","eq","11"]`, "id")
+			return map[string]any{"previous": len(previous), "current": ids(current)}, err
+		}, map[string]any{"previous": 0, "current": []any{float64(1), float64(2)}}),
+
+		newServerCase(server, "Get list Employee by the index after a delete", employees, func(client *resty.Client) (any, error) {
+			if _, err := pkg.Request(client, http.MethodDelete, "/Employee/3", nil, nil); err != nil {
+				return nil, err
+			}
+			records, err := list(client, `["age","gte","11"]`, "id")
+			return ids(records), err
+		}, []any{float64(1), float64(2)}),
+	}
+}
+
+// NewNullSuite returns the cases of the filters with an empty value, only the first employee has a nickname
+func NewNullSuite(server *Server) []pkg.ITestCase {
+	seed := func(client *resty.Client) error {
+		_, err := pkg.Request(client, http.MethodPut, "/Employee/1", map[string]any{"nickname": "D"}, nil)
+		return err
+	}
+	filter := func(filter string) func(client *resty.Client) (any, error) {
+		return func(client *resty.Client) (any, error) {
+			if err := seed(client); err != nil {
+				return nil, err
+			}
+			records, err := list(client, filter, "id")
+			return ids(records), err
+		}
+	}
+	return []pkg.ITestCase{
+		newServerCase(server, "Get list Employee without nickname", employees,
+			filter(`["nickname","_null",""]`), []any{float64(2), float64(3)}),
+		newServerCase(server, "Get list Employee with a nickname", employees,
+			filter(`["nickname","_nnull",""]`), []any{float64(1)}),
+		newServerCase(server, "Get list Employee with a nickname or an empty phone", employees,
+			filter(`[["nickname","_nnull",""],"_or",["phone","eq",""]]`), []any{float64(1), float64(2)}),
+	}
+}
+
+// NewGinSuite returns the cases of the custom actions mounted on gin
+func NewGinSuite(server *Server) []pkg.ITestCase {
+	return []pkg.ITestCase{
+		newServerCase(server, "Run the birthday action of Employee", employees[:1], func(client *resty.Client) (any, error) {
+			var updated, detail map[string]any
+			status, err := pkg.Request(client, http.MethodPost, "/Employee/1/_actions/birthday", nil, &updated)
+			if err != nil {
+				return nil, err
+			}
+			modelStatus, err := pkg.Request(client, http.MethodPost, "/Employee/_actions/birthday", nil, nil)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := pkg.Request(client, http.MethodGet, "/Employee/1", nil, &detail); err != nil {
+				return nil, err
+			}
+			return map[string]any{"status": status, "age": updated["age"], "model": modelStatus, "detail": detail["age"]}, nil
+		}, map[string]any{"status": http.StatusOK, "age": float64(28), "model": http.StatusBadRequest, "detail": float64(28)}),
+
+		newServerCase(server, "Run the card action of Employee writing its response", employees[:1], func(client *resty.Client) (any, error) {
+			resp, err := pkg.Send(client, http.MethodGet, "/Employee/1/_actions/card", nil)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{"status": resp.StatusCode(), "type": resp.Header().Get("Content-Type"), "body": resp.String()}, nil
+		}, map[string]any{"status": http.StatusOK, "type": "text/plain", "body": "Happy birthday " + employees[0]["Name"].(string)}),
+	}
+}
+
+// NewTypedSuite returns the cases of the permissions of the fields of the typed accounts
+func NewTypedSuite(server *Server) []pkg.ITestCase {
+	return []pkg.ITestCase{
+		newServerCase(server, "Create Account with a protected field by its json name", nil, func(client *resty.Client) (any, error) {
+			var denied map[string]any
+			status, err := pkg.Request(client, http.MethodPost, "/Account", map[string]any{"login": "duy", "secret": "1234"}, &denied)
+			if err != nil {
+				return nil, err
+			}
+			allowed, err := pkg.Request(client.SetHeader("X-Role", "hr"), http.MethodPost, "/Account",
+				map[string]any{"login": "duy", "secret": "1234"}, nil)
+			return map[string]any{"status": status, "errors": denied["errors"], "allowed": allowed}, err
+		}, map[string]any{"status": http.StatusForbidden, "allowed": http.StatusCreated,
+			"errors": map[string]any{"secret": "you don't have permission to write secret"}}),
+
+		newServerCase(server, "Create Badge with typed and untyped hooks", nil, func(client *resty.Client) (any, error) {
+			invalid, err := pkg.Request(client, http.MethodPost, "/Badge", map[string]any{"label": ""}, nil)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := pkg.Send(client, http.MethodPost, "/Badge", map[string]any{"label": "gold"})
+			if err != nil {
+				return nil, err
+			}
+			var created map[string]any
+			if err := json.Unmarshal(resp.Body(), &created); err != nil {
+				return nil, err
+			}
+			return map[string]any{"invalid": invalid, "status": resp.StatusCode(), "label": created["label"],
+				"location": resp.Header().Get("Location")}, nil
+		}, map[string]any{"invalid": http.StatusUnprocessableEntity, "status": http.StatusCreated, "label": "GOLD",
+			"location": crud_generator.DefaultBasePath + "/Badge/1"}),
+
+		newServerCase(server, "Get Account with a field masked by the role", nil, func(client *resty.Client) (any, error) {
+			hr := pkg.NewHTTPClient(server.Url()).SetHeader("X-Role", "hr")
+			if _, err := pkg.Request(hr, http.MethodPost, "/Account", map[string]any{"login": "duy", "salary": 100}, nil); err != nil {
+				return nil, err
+			}
+			var masked, allowed map[string]any
+			if _, err := pkg.Request(client, http.MethodGet, "/Account/1", nil, &masked); err != nil {
+				return nil, err
+			}
+			if _, err := pkg.Request(hr, http.MethodGet, "/Account/1", nil, &allowed); err != nil {
+				return nil, err
+			}
+			_, hidden := masked["salary"]
+			filtered, err := pkg.Request(client, http.MethodGet, `/Account?page=1&page_size=10&filter=["salary","gt","50"]`, nil, nil)
+			return map[string]any{"login": masked["login"], "masked": !hidden, "salary": allowed["salary"], "filter": filtered}, err
+		}, map[string]any{"login": "duy", "masked": true, "salary": float64(100), "filter": http.StatusForbidden}),
+	}
+}
+
+// NewTracingSuite returns the cases of the spans recorded into exporter by the server
+func NewTracingSuite(server *Server, exporter *tracetest.InMemoryExporter) []pkg.ITestCase {
+	return []pkg.ITestCase{
+		newServerCase(server, "Trace Create Employee", nil, func(client *resty.Client) (any, error) {
+			client.SetHeader("traceparent", traceParent)
+			if _, err := pkg.Request(client, http.MethodPost, "/Employee", employees[0], nil); err != nil {
+				return nil, err
+			}
+
+			spans := exporter.GetSpans()
+			actual := make(map[string]any)
+			for _, span := range spans {
+				if span.Name != "crud create Employee" {
+					continue
+				}
+				actual["handler.name"] = span.Name
+				actual["handler.trace"] = span.SpanContext.TraceID().String()
+				actual["handler.parent"] = span.Parent.SpanID().String()
+				for _, attr := range span.Attributes {
+					switch attr.Key {
+					case "crud.model", "crud.operation", "crud.result.count":
+						actual[string(attr.Key)] = attr.Value.AsInterface()
+					}
+				}
+				for _, child := range spans {
+					if child.Parent.SpanID() == span.SpanContext.SpanID() {
+						actual["repository.name"] = child.Name
+						actual["repository.parent"] = child.SpanContext.TraceID() == span.SpanContext.TraceID()
+					}
+				}
+			}
+			return actual, nil
+		}, map[string]any{
+			"handler.name":      "crud create Employee",
+			"handler.trace":     traceID,
+			"handler.parent":    parentID,
+			"crud.model":        "Employee",
+			"crud.operation":    "create",
+			"crud.result.count": int64(1),
+			"repository.name":   "repository.Create",
+			"repository.parent": true,
+		}),
+	}
+}
+
+// newServerCase returns the case of name run on server, the employees of seed are created through the api,
+// then do returns the actual result of the case
+func newServerCase(server *Server, name string, seed []map[string]any,
+	do func(client *resty.Client) (any, error), expect any) pkg.ITestCase {
+	var actual any
+	return pkg.NewTestCase(&pkg.NewTestCaseDTO{
+		Name: fmt.Sprintf("%s on %s", name, server.Name()),
+		Preparing: func() error {
+			if err := server.Reset(); err != nil {
+				return err
+			}
+			client := pkg.NewHTTPClient(server.Url())
+			for _, employee := range seed {
+				status, err := pkg.Request(client, http.MethodPost, "/Employee", employee, nil)
+				if err != nil {
+					return err
+				}
+				if status != http.StatusCreated {
+					return fmt.Errorf("creating %v responded %d", employee["Name"], status)
+				}
+			}
+			return nil
+		},
+		Do: func() error {
+			var err error
+			actual, err = do(pkg.NewHTTPClient(server.Url()))
+			return err
+		},
+		GetExpected: func() (any, error) {
+			return expect, nil
+		},
+		GetActual: func() (any, error) {
+			return actual, nil
+		},
+	})
+}
+
+// list returns the summaries of the employees of the first page of the filter
+func list(client *resty.Client, filter, orderBy string) ([]map[string]any, error) {
+	params := url.Values{"page": {"1"}, "page_size": {"10"}}
+	if filter != "" {
+		params.Set("filter", filter)
+	}
+	if orderBy != "" {
+		params.Set("order_by", orderBy)
+	}
+
+	var records []map[string]any
+	status, err := pkg.Request(client, http.MethodGet, "/Employee?"+params.Encode(), nil, &records)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("get list responded %d", status)
+	}
+	summaries := make([]map[string]any, 0, len(records))
+	for _, record := range records {
+		summaries = append(summaries, summary(record))
+	}
+	return summaries, nil
+}
+
+// ids returns the ids of the summaries
+func ids(summaries []map[string]any) []any {
+	result := make([]any, 0, len(summaries))
+	for _, summary := range summaries {
+		result = append(result, summary["id"])
+	}
+	return result
+}
+
+// summary returns the fields of the employee compared by the cases, the dates are compared without their time
+// as each repository formats them its own way
+func summary(record map[string]any) map[string]any {
+	dob := fmt.Sprint(record["dob"])
+	if len(dob) > len("2006-01-02") {
+		dob = dob[:len("2006-01-02")]
+	}
+	return map[string]any{
+		"id":     record["id"],
+		"name":   record["name"],
+		"email":  record["email"],
+		"dob":    dob,
+		"age":    record["age"],
+		"phone":  record["phone"],
+		"mature": record["mature"],
+	}
+}
+
+// expected returns the summary of the employee of id created with data
+func expected(id int, data map[string]any) map[string]any {
+	return map[string]any{
+		"id":     float64(id),
+		"name":   data["Name"],
+		"email":  data["Email"],
+		"dob":    data["Dob"],
+		"age":    float64(data["Age"].(int)),
+		"phone":  data["Phone"],
+		"mature": data["Mature"],
+	}
+}
@@ -0,0 +1,216 @@
+package crud_generator
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/duytacong24895/go-crud-generator/core"
+	"github.com/duytacong24895/go-crud-generator/errs"
+)
+
+// Resource is a model registered with Register, its hooks, validators and DTOs receive
+// the records as *T, the records are scanned into T so the gorm serializers and the json tags apply
+type Resource[T any] struct {
+	model *core.Model
+}
+
+// Register registers T as a model of the generator, the untyped API can still be used for the model
+// Example: crud_generator.Register[models.User](generator).Validate(validateUser)
+func Register[T any](generator ICRUDGenerator, opts ...ModelOption) *Resource[T] {
+	registerer, ok := generator.(interface{ registeredModel(ref any) *core.Model })
+	if !ok {
+		panic(fmt.Sprintf("Register doesn't support the generator %T", generator))
+	}
+
+	model := registerer.registeredModel(new(T))
+	model.Typed = true
+	for _, opt := range opts {
+		opt(model)
+	}
+	return &Resource[T]{model: model}
+}
+
+// Model returns the untyped model of the resource
+func (r *Resource[T]) Model() *core.Model {
+	return r.model
+}
+
+// Validate adds a validator run before the creates and the updates,
+// the record of an update is the current record with the changes of the payload
+func (r *Resource[T]) Validate(validator func(ctx context.Context, record *T) error) *Resource[T] {
+	r.BeforeCreate(validator)
+	return r.BeforeUpdate(func(ctx context.Context, current, updated *T) error {
+		return validator(ctx, updated)
+	})
+}
+
+// BeforeCreate adds a hook run before the creates, the fields changed by the hook are created as well
+func (r *Resource[T]) BeforeCreate(hook func(ctx context.Context, record *T) error) *Resource[T] {
+	previous := r.model.Hooks.BeforeCreate
+	r.model.Hooks.BeforeCreate = func(ctx context.Context, model *core.Model, payload *map[string]any) error {
+		if previous != nil {
+			if err := previous(ctx, model, payload); err != nil {
+				return err
+			}
+		}
+		return r.editPayload(nil, payload, func(record *T) error {
+			return hook(ctx, record)
+		})
+	}
+	return r
+}
+
+// BeforeUpdate adds a hook run before the updates, updated is the current record with the changes
+// of the payload, the fields changed by the hook are updated as well
+func (r *Resource[T]) BeforeUpdate(hook func(ctx context.Context, current, updated *T) error) *Resource[T] {
+	previous := r.model.Hooks.BeforeUpdate
+	r.model.Hooks.BeforeUpdate = func(ctx context.Context, model *core.Model, record, payload *map[string]any) error {
+		if previous != nil {
+			if err := previous(ctx, model, record, payload); err != nil {
+				return err
+			}
+		}
+
+		current := new(T)
+		if err := (core.Core{}).MapToStruct(*record, current); err != nil {
+			return errs.Internal("", err)
+		}
+		return r.editPayload(*record, payload, func(updated *T) error {
+			return hook(ctx, current, updated)
+		})
+	}
+	return r
+}
+
+func (r *Resource[T]) AfterCreate(hook func(ctx context.Context, record *T) error) *Resource[T] {
+	r.model.Hooks.AfterCreate = r.recordHook(r.model.Hooks.AfterCreate, hook)
+	return r
+}
+
+func (r *Resource[T]) AfterUpdate(hook func(ctx context.Context, record *T) error) *Resource[T] {
+	r.model.Hooks.AfterUpdate = r.recordHook(r.model.Hooks.AfterUpdate, hook)
+	return r
+}
+
+func (r *Resource[T]) BeforeDelete(hook func(ctx context.Context, record *T) error) *Resource[T] {
+	r.model.Hooks.BeforeDelete = r.recordHook(r.model.Hooks.BeforeDelete, hook)
+	return r
+}
+
+func (r *Resource[T]) AfterDelete(hook func(ctx context.Context, record *T) error) *Resource[T] {
+	r.model.Hooks.AfterDelete = r.recordHook(r.model.Hooks.AfterDelete, hook)
+	return r
+}
+
+// AfterRead adds a hook run on every record returned to the client, the changes of the hook are returned
+func (r *Resource[T]) AfterRead(hook func(ctx context.Context, record *T) error) *Resource[T] {
+	r.model.Hooks.AfterRead = r.recordHook(r.model.Hooks.AfterRead, hook)
+	return r
+}
+
+// WithDTOGetDetail sets the DTO of get detail of the model, record is nil for the responses without a record
+func (r *Resource[T]) WithDTOGetDetail(returndto func(w http.ResponseWriter, req *http.Request, record *T) any) *Resource[T] {
+	r.model.DTOGetDetail = func(w http.ResponseWriter, req *http.Request, ref any) any {
+		entity, ok := ref.(*map[string]any)
+		if ref != nil && !ok {
+			// The results of the custom actions are not records
+			return ref
+		}
+		if entity == nil {
+			return returndto(w, req, nil)
+		}
+
+		record := new(T)
+		if err := (core.Core{}).MapToStruct(*entity, record); err != nil {
+			return ref
+		}
+		return returndto(w, req, record)
+	}
+	return r
+}
+
+func (r *Resource[T]) WithDTOGetList(returndto func(w http.ResponseWriter, req *http.Request,
+	records []T, total, page, pageSize uint) any) *Resource[T] {
+	r.model.DTOGetList = func(w http.ResponseWriter, req *http.Request, ref any, total, page, pageSize uint) any {
+		entities, _ := ref.([]*map[string]any)
+		records := make([]T, len(entities))
+		for i, entity := range entities {
+			if err := (core.Core{}).MapToStruct(*entity, &records[i]); err != nil {
+				return ref
+			}
+		}
+		return returndto(w, req, records, total, page, pageSize)
+	}
+	return r
+}
+
+// recordHook runs hook on the record decoded into T after previous,
+// the record is updated with the changes of the hook
+func (r *Resource[T]) recordHook(previous func(ctx context.Context, model *core.Model, record *map[string]any) error,
+	hook func(ctx context.Context, record *T) error) func(ctx context.Context, model *core.Model, record *map[string]any) error {
+	return func(ctx context.Context, model *core.Model, entity *map[string]any) error {
+		if previous != nil {
+			if err := previous(ctx, model, entity); err != nil {
+				return err
+			}
+		}
+
+		record := new(T)
+		if err := (core.Core{}).MapToStruct(*entity, record); err != nil {
+			return errs.Internal("", err)
+		}
+		if err := hook(ctx, record); err != nil {
+			return err
+		}
+		changed, err := core.Core{}.StructToMap(record)
+		if err != nil {
+			return errs.Internal("", err)
+		}
+		for key, value := range changed {
+			(*entity)[key] = value
+		}
+		return nil
+	}
+}
+
+// editPayload runs edit on the payload, merged into base, decoded into T,
+// the fields changed by edit are written to the payload by their column names
+func (r *Resource[T]) editPayload(base map[string]any, payload *map[string]any, edit func(record *T) error) error {
+	merged := make(map[string]any, len(base)+len(*payload))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range *payload {
+		merged[key] = value
+	}
+
+	record := new(T)
+	if err := (core.Core{}).MapToStruct(merged, record); err != nil {
+		return errs.BadRequest(fmt.Sprintf("invalid request body: %v", err), err)
+	}
+	before, err := core.Core{}.StructToMap(record)
+	if err != nil {
+		return errs.Internal("", err)
+	}
+	if err := edit(record); err != nil {
+		return err
+	}
+	after, err := core.Core{}.StructToMap(record)
+	if err != nil {
+		return errs.Internal("", err)
+	}
+
+	value := reflect.ValueOf(record).Elem()
+	for key := range after {
+		field, ok := r.model.Meta.Field(key)
+		if !ok || reflect.DeepEqual(before[key], after[key]) {
+			continue
+		}
+		delete(*payload, field.Name)
+		delete(*payload, field.JSONName)
+		(*payload)[field.DBName] = value.FieldByName(field.Name).Interface()
+	}
+	return nil
+}